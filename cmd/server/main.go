@@ -1,19 +1,103 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"math/rand"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // часовые пояса пользователей нужны и в образе без tzdata
 
+	"github.com/iamyblitz/pr-reviewer-service/internal/backup"
 	httpapi "github.com/iamyblitz/pr-reviewer-service/internal/http"
 	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
 	"github.com/iamyblitz/pr-reviewer-service/internal/service"
 )
 
 func main() {
+	storage := flag.String("storage", envOr("STORAGE", "memory"), "storage backend: memory, postgres or sqlite")
+	databaseURL := flag.String("database-url", os.Getenv("DATABASE_URL"), "postgres connection string")
+	sqlitePath := flag.String("sqlite-path", envOr("SQLITE_PATH", "pr-reviewer.db"), "sqlite database file")
+	dataDir := flag.String("data-dir", os.Getenv("DATA_DIR"), "directory for memory storage snapshot and journal (empty = no persistence)")
+	autoMigrate := flag.Bool("auto-migrate", os.Getenv("AUTO_MIGRATE") != "false", "apply pending migrations before serving")
+	strategy := flag.String("reviewer-strategy", envOr("REVIEWER_STRATEGY", service.StrategyLeastLoaded),
+		"default reviewer selection strategy: "+strings.Join(service.StrategyNames(), ", "))
+	seed := flag.Int64("seed", envInt64("SEED"), "seed for random reviewer selection (0 = random, logged at startup)")
+	flag.Usage = usage
+	flag.Parse()
 
-	r := repo.NewMemoryRepo()
-	svc := service.NewService(r)
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	switch args[0] {
+	case "serve", "migrate", "export", "import":
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if !service.KnownStrategy(*strategy) {
+		log.Fatalf("unknown reviewer strategy %q", *strategy)
+	}
+
+	r, err := openRepo(*storage, *databaseURL, *sqlitePath, *dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// схема нужна и серверу, и импорту в свежую БД
+	if m, ok := r.(repo.Migrator); ok && *autoMigrate && (args[0] == "serve" || args[0] == "import") {
+		if _, err := m.MigrateUp(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	switch args[0] {
+	case "serve":
+		// seed печатается всегда: с ним можно повторить те же назначения
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		log.Printf("random seed %d", *seed)
+		serve(r, service.WithStrategy(*strategy), service.WithRand(rand.NewSource(*seed)))
+	case "migrate":
+		err = runMigrate(r, args[1:])
+	case "export":
+		err = runExport(r, args[1:])
+	case "import":
+		err = runImport(r, args[1:])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `usage: %s [flags] [command]
+
+commands:
+  serve               run HTTP server (default)
+  migrate up          apply all pending migrations
+  migrate down [N]    roll back the last N migrations (default 1)
+  migrate status      list migrations and whether they are applied
+  export [-format json|ndjson] [-o FILE]
+                      dump teams, users, PRs with their assignment logs,
+                      pairings and absences (default: json to stdout)
+  import [FILE]       load a dump (json or ndjson, default: stdin) into empty storage
+
+flags:
+`, os.Args[0])
+	flag.PrintDefaults()
+}
+
+func serve(r repo.Repository, opts ...service.Option) {
+	svc := service.NewService(r, opts...)
 
 	router := httpapi.NewRouter(svc)
 
@@ -21,5 +105,189 @@ func main() {
 	if err := http.ListenAndServe(":8080", router); err != nil {
 		log.Fatal(err)
 	}
+}
+
+func runMigrate(r repo.Repository, args []string) error {
+	m, ok := r.(repo.Migrator)
+	if !ok {
+		return fmt.Errorf("migrations are only supported for postgres and sqlite storage")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("migrate: expected up, down or status")
+	}
 
+	switch args[0] {
+	case "up":
+		applied, err := m.MigrateUp()
+		for _, mg := range applied {
+			fmt.Printf("applied %04d_%s\n", mg.Version, mg.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("nothing to apply")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("migrate down: bad step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := m.MigrateDown(steps)
+		for _, mg := range reverted {
+			fmt.Printf("reverted %04d_%s\n", mg.Version, mg.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("nothing to revert")
+		}
+	case "status":
+		statuses, err := m.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", st.Version, st.Name, state)
+		}
+	default:
+		return fmt.Errorf("migrate: unknown command %q", args[0])
+	}
+	return nil
+}
+
+func runExport(r repo.Repository, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "json", "dump format: json or ndjson")
+	out := fs.String("o", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "ndjson" {
+		return fmt.Errorf("export: unknown format %q", *format)
+	}
+
+	dump, err := backup.Export(context.Background(), r, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "ndjson" {
+		err = backup.WriteNDJSON(w, dump)
+	} else {
+		err = backup.WriteJSON(w, dump)
+	}
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		return w.Close()
+	}
+	return nil
+}
+
+func runImport(r repo.Repository, args []string) error {
+	in := os.Stdin
+	if len(args) > 0 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	dump, err := backup.Read(in)
+	if err != nil {
+		return err
+	}
+	report, err := backup.Import(context.Background(), r, dump)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d teams, %d users, %d pull requests, %d assignment records, %d pairings, %d absences\n",
+		report.Teams, report.Users, report.PullRequests, report.AssignmentRecords, report.Pairings, report.Absences)
+	if len(report.Skipped) > 0 {
+		fmt.Printf("skipped %s\n", skippedSummary(report.Skipped))
+	}
+	for _, sk := range report.Skipped {
+		fmt.Printf("skipped %s %q: %s\n", sk.Kind, sk.ID, sk.Reason)
+	}
+	return nil
+}
+
+// skippedSummary считает пропущенные записи по видам в порядке их появления:
+// "2 user, 1 pairing".
+func skippedSummary(skipped []backup.Skipped) string {
+	var kinds []string
+	counts := make(map[string]int)
+	for _, sk := range skipped {
+		if counts[sk.Kind] == 0 {
+			kinds = append(kinds, sk.Kind)
+		}
+		counts[sk.Kind]++
+	}
+	parts := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[k], k))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func openRepo(storage, databaseURL, sqlitePath, dataDir string) (repo.Repository, error) {
+	switch storage {
+	case "memory":
+		if dataDir != "" {
+			return repo.OpenMemoryRepo(dataDir, repo.DefaultSnapshotEvery)
+		}
+		return repo.NewMemoryRepo(), nil
+	case "postgres":
+		if databaseURL == "" {
+			return nil, fmt.Errorf("database url is required for postgres storage")
+		}
+		return repo.OpenPostgres(databaseURL)
+	case "sqlite":
+		return repo.OpenSQLite(sqlitePath)
+	default:
+		return nil, fmt.Errorf("unknown storage %q", storage)
+	}
+}
+
+func envInt64(key string) int64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		log.Fatalf("bad %s %q: %v", key, v, err)
+	}
+	return n
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
 }
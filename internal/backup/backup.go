@@ -0,0 +1,341 @@
+// Package backup выгружает всё состояние repo.Repository в дамп и загружает
+// его обратно в пустое хранилище.
+//
+// Дамп бывает двух видов:
+//
+//   - JSON — один объект {"format", "version", "exported_at", "teams", "pull_requests", "pairings", "absences"};
+//   - NDJSON — по записи на строку: сначала заголовок {"kind":"header", ...},
+//     затем записи {"kind":"team", ...}, {"kind":"pull_request", ...}, {"kind":"pairing", ...}
+//     и {"kind":"absence", ...}.
+//
+// Пользователи и настройки хранятся внутри своих команд, ревьюверы — внутри PR.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+const (
+	FormatName    = "pr-reviewer-dump"
+	FormatVersion = 1
+)
+
+const (
+	kindHeader      = "header"
+	kindTeam        = "team"
+	kindPullRequest = "pull_request"
+	kindPairing     = "pairing"
+	kindAbsence     = "absence"
+)
+
+var ErrBadDump = errors.New("invalid dump")
+
+type Dump struct {
+	Format       string        `json:"format"`
+	Version      int           `json:"version"`
+	ExportedAt   time.Time     `json:"exported_at"`
+	Teams        []Team        `json:"teams"`
+	PullRequests []PullRequest `json:"pull_requests"`
+	Pairings     []Pairing     `json:"pairings,omitempty"`
+	Absences     []Absence     `json:"absences,omitempty"`
+}
+
+type Team struct {
+	Name     string             `json:"team_name"`
+	Members  []User             `json:"members"`
+	Settings model.TeamSettings `json:"settings"`
+}
+
+type User struct {
+	ID       string             `json:"user_id"`
+	Username string             `json:"username"`
+	IsActive bool               `json:"is_active"`
+	Settings model.UserSettings `json:"settings"`
+}
+
+type PullRequest struct {
+	ID                string     `json:"pull_request_id"`
+	Name              string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	Status            string     `json:"status"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	ChangedPaths      []string   `json:"changed_paths,omitempty"`
+	ExcludedReviewers []string   `json:"excluded_reviewers,omitempty"`
+	ShadowReviewer    string     `json:"shadow_reviewer,omitempty"`
+	CreatedAt         *time.Time `json:"created_at,omitempty"`
+	MergedAt          *time.Time `json:"merged_at,omitempty"`
+
+	AssignmentLog []model.AssignmentRecord `json:"assignment_log,omitempty"`
+}
+
+// Pairing — кто чей PR ревьюил и когда.
+type Pairing struct {
+	AuthorID      string    `json:"author_id"`
+	ReviewerID    string    `json:"reviewer_id"`
+	PullRequestID string    `json:"pull_request_id"`
+	AssignedAt    time.Time `json:"assigned_at"`
+}
+
+// Absence — период, когда пользователя нельзя назначать ревьювером.
+type Absence struct {
+	ID     string    `json:"absence_id"`
+	UserID string    `json:"user_id"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Export читает все команды, PR и историю назначений из r одной
+// транзакцией, чтобы дамп был согласованным: PR, созданный во время
+// экспорта, не попадёт в него без своего автора.
+func Export(ctx context.Context, r repo.Repository, now time.Time) (*Dump, error) {
+	var d *Dump
+	err := r.WithTx(repo.WithSnapshot(ctx), func(tx repo.Repository) error {
+		var err error
+		d, err = export(ctx, tx, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func export(ctx context.Context, r repo.Repository, now time.Time) (*Dump, error) {
+	teams, err := r.ListTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list teams: %w", err)
+	}
+	prs, err := r.ListPullRequests(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list pull requests: %w", err)
+	}
+	pairings, err := r.ListPairings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list pairings: %w", err)
+	}
+
+	d := &Dump{
+		Format:       FormatName,
+		Version:      FormatVersion,
+		ExportedAt:   now,
+		Teams:        make([]Team, 0, len(teams)),
+		PullRequests: make([]PullRequest, 0, len(prs)),
+	}
+	for _, t := range teams {
+		d.Teams = append(d.Teams, newTeam(t))
+		for _, u := range t.Members {
+			absences, err := r.ListAbsences(ctx, u.ID)
+			if err != nil {
+				return nil, fmt.Errorf("list absences of %q: %w", u.ID, err)
+			}
+			for _, a := range absences {
+				d.Absences = append(d.Absences, Absence(a))
+			}
+		}
+	}
+	for _, pr := range prs {
+		p := newPullRequest(pr)
+		if p.AssignmentLog, err = r.ListAssignmentRecords(ctx, pr.ID); err != nil {
+			return nil, fmt.Errorf("list assignment log of %q: %w", pr.ID, err)
+		}
+		d.PullRequests = append(d.PullRequests, p)
+	}
+	for _, p := range pairings {
+		d.Pairings = append(d.Pairings, Pairing(p))
+	}
+	return d, nil
+}
+
+func newTeam(t model.Team) Team {
+	team := Team{Name: t.Name, Members: make([]User, 0, len(t.Members)), Settings: t.Settings}
+	for _, u := range t.Members {
+		team.Members = append(team.Members, User{ID: u.ID, Username: u.Username, IsActive: u.IsActive, Settings: u.Settings})
+	}
+	return team
+}
+
+func newPullRequest(pr model.PullRequest) PullRequest {
+	reviewers := pr.AssignedReviewers
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+	return PullRequest{
+		ID:                pr.ID,
+		Name:              pr.Name,
+		AuthorID:          pr.AuthorID,
+		Status:            string(pr.Status),
+		AssignedReviewers: reviewers,
+		ChangedPaths:      pr.ChangedPaths,
+		ExcludedReviewers: pr.ExcludedReviewers,
+		ShadowReviewer:    pr.ShadowReviewer,
+		CreatedAt:         pr.CreatedAt,
+		MergedAt:          pr.MergedAt,
+	}
+}
+
+// WriteJSON пишет дамп одним JSON-объектом.
+func WriteJSON(w io.Writer, d *Dump) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+type header struct {
+	Kind       string    `json:"kind"`
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+type teamLine struct {
+	Kind string `json:"kind"`
+	Team
+}
+
+type pullRequestLine struct {
+	Kind string `json:"kind"`
+	PullRequest
+}
+
+type pairingLine struct {
+	Kind string `json:"kind"`
+	Pairing
+}
+
+type absenceLine struct {
+	Kind string `json:"kind"`
+	Absence
+}
+
+// WriteNDJSON пишет дамп по записи на строку.
+func WriteNDJSON(w io.Writer, d *Dump) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	if err := enc.Encode(header{Kind: kindHeader, Format: d.Format, Version: d.Version, ExportedAt: d.ExportedAt}); err != nil {
+		return err
+	}
+	for _, t := range d.Teams {
+		if err := enc.Encode(teamLine{Kind: kindTeam, Team: t}); err != nil {
+			return err
+		}
+	}
+	for _, pr := range d.PullRequests {
+		if err := enc.Encode(pullRequestLine{Kind: kindPullRequest, PullRequest: pr}); err != nil {
+			return err
+		}
+	}
+	for _, p := range d.Pairings {
+		if err := enc.Encode(pairingLine{Kind: kindPairing, Pairing: p}); err != nil {
+			return err
+		}
+	}
+	for _, a := range d.Absences {
+		if err := enc.Encode(absenceLine{Kind: kindAbsence, Absence: a}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Read разбирает дамп в любом из двух форматов: NDJSON узнаётся
+// по заголовку {"kind":"header"} в первой записи.
+func Read(r io.Reader) (*Dump, error) {
+	dec := json.NewDecoder(r)
+
+	var first json.RawMessage
+	// ошибку чтения оборачиваем целиком: вызывающему может быть важна
+	// её причина (например, *http.MaxBytesError)
+	if err := dec.Decode(&first); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadDump, err)
+	}
+	var probe struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(first, &probe); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadDump, err)
+	}
+
+	var d Dump
+	if probe.Kind == kindHeader {
+		if err := readNDJSON(dec, first, &d); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := json.Unmarshal(first, &d); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadDump, err)
+		}
+	}
+
+	if d.Format != FormatName {
+		return nil, fmt.Errorf("%w: unknown format %q", ErrBadDump, d.Format)
+	}
+	if d.Version != FormatVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d (want %d)", ErrBadDump, d.Version, FormatVersion)
+	}
+	return &d, nil
+}
+
+func readNDJSON(dec *json.Decoder, first json.RawMessage, d *Dump) error {
+	var h header
+	if err := json.Unmarshal(first, &h); err != nil {
+		return fmt.Errorf("%w: header: %v", ErrBadDump, err)
+	}
+	d.Format, d.Version, d.ExportedAt = h.Format, h.Version, h.ExportedAt
+
+	for line := 2; ; line++ {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: record %d: %w", ErrBadDump, line, err)
+		}
+
+		var probe struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			return fmt.Errorf("%w: record %d: %v", ErrBadDump, line, err)
+		}
+		switch probe.Kind {
+		case kindTeam:
+			var t teamLine
+			if err := json.Unmarshal(raw, &t); err != nil {
+				return fmt.Errorf("%w: record %d: %v", ErrBadDump, line, err)
+			}
+			d.Teams = append(d.Teams, t.Team)
+		case kindPullRequest:
+			var pr pullRequestLine
+			if err := json.Unmarshal(raw, &pr); err != nil {
+				return fmt.Errorf("%w: record %d: %v", ErrBadDump, line, err)
+			}
+			d.PullRequests = append(d.PullRequests, pr.PullRequest)
+		case kindPairing:
+			var p pairingLine
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return fmt.Errorf("%w: record %d: %v", ErrBadDump, line, err)
+			}
+			d.Pairings = append(d.Pairings, p.Pairing)
+		case kindAbsence:
+			var a absenceLine
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("%w: record %d: %v", ErrBadDump, line, err)
+			}
+			d.Absences = append(d.Absences, a.Absence)
+		default:
+			return fmt.Errorf("%w: record %d: unknown kind %q", ErrBadDump, line, probe.Kind)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/backup"
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+var exportedAt = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+// populated возвращает хранилище со всеми видами записей дампа: командами
+// с настройками, открытым и слитым PR с журналом назначений, парами
+// и отсутствием.
+func populated(t *testing.T) repo.Repository {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	r := repo.NewMemoryRepo()
+	s := service.NewService(r, service.WithClock(func() time.Time { return now }))
+
+	members := []model.User{
+		{ID: "a", Username: "alice", IsActive: true, Settings: model.UserSettings{Level: model.LevelSenior}},
+		{ID: "b", Username: "bob", IsActive: true},
+		{ID: "c", Username: "carol", IsActive: true},
+		{ID: "d", Username: "dave", IsActive: false},
+	}
+	settings := model.TeamSettings{ReviewerCount: 1, MaxOpenReviews: 3, FallbackTeams: []string{"frontend"}}
+	if _, err := s.CreateTeam(ctx, "backend", members, settings); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if _, err := s.CreateTeam(ctx, "frontend", []model.User{{ID: "f", Username: "frank", IsActive: true}}, model.TeamSettings{}); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+
+	opts := service.CreatePROptions{ChangedPaths: []string{"api/x.go"}, ExcludedReviewers: []string{"c"}}
+	res, err := s.CreatePullRequest(ctx, "pr-1", "open", "a", opts)
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if _, err := s.ReassignReviewer(ctx, "pr-1", res.PR.AssignedReviewers[0], 0); err != nil {
+		t.Fatalf("ReassignReviewer: %v", err)
+	}
+	if _, err := s.CreatePullRequest(ctx, "pr-2", "merged", "b", service.CreatePROptions{}); err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if _, err := s.MergePullRequest(ctx, "pr-2", 0); err != nil {
+		t.Fatalf("MergePullRequest: %v", err)
+	}
+	if _, err := s.AddAbsence(ctx, "c", now, now.Add(48*time.Hour), "vacation"); err != nil {
+		t.Fatalf("AddAbsence: %v", err)
+	}
+	return r
+}
+
+func export(t *testing.T, r repo.Repository) *backup.Dump {
+	t.Helper()
+	d, err := backup.Export(context.Background(), r, exportedAt)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	return d
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(io.Writer, *backup.Dump) error
+	}{
+		{"json", backup.WriteJSON},
+		{"ndjson", backup.WriteNDJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := export(t, populated(t))
+
+			var buf bytes.Buffer
+			if err := tt.write(&buf, want); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			read, err := backup.Read(&buf)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			target := repo.NewMemoryRepo()
+			rep, err := backup.Import(context.Background(), target, read)
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+			wantReport := backup.Report{
+				Teams:             2,
+				Users:             5,
+				PullRequests:      2,
+				AssignmentRecords: 3,
+				Pairings:          3,
+				Absences:          1,
+				Skipped:           []backup.Skipped{},
+			}
+			if !reflect.DeepEqual(*rep, wantReport) {
+				t.Errorf("report %+v, want %+v", *rep, wantReport)
+			}
+
+			if got := export(t, target); !reflect.DeepEqual(got, want) {
+				t.Errorf("dump after round trip differs:\ngot  %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestImportSkipped(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	d := &backup.Dump{
+		Format:  backup.FormatName,
+		Version: backup.FormatVersion,
+		Teams: []backup.Team{
+			{Name: "backend", Members: []backup.User{{ID: "a", IsActive: true}, {ID: "b", IsActive: true}, {ID: ""}}},
+			{Name: "backend"},
+			{Name: "bad", Settings: model.TeamSettings{ReviewerCount: -1}},
+			{Name: "frontend", Members: []backup.User{{ID: "a"}, {ID: "f", Settings: model.UserSettings{MaxOpenReviews: -1}}}},
+		},
+		PullRequests: []backup.PullRequest{
+			{ID: "pr-1", Name: "ok", AuthorID: "a", Status: "open", AssignedReviewers: []string{"b", "a", "x", "b"}, ShadowReviewer: "x"},
+			{ID: "pr-1", Name: "again", AuthorID: "a", Status: "open"},
+			{ID: "pr-2", Name: "status", AuthorID: "a", Status: "CLOSED"},
+			{ID: "pr-3", Name: "author", AuthorID: "x", Status: "open"},
+		},
+		Pairings: []backup.Pairing{
+			{AuthorID: "a", ReviewerID: "b", PullRequestID: "pr-1", AssignedAt: at},
+			{AuthorID: "a", ReviewerID: "x", PullRequestID: "pr-1", AssignedAt: at},
+			{AuthorID: "a", ReviewerID: "b", PullRequestID: "pr-0"},
+		},
+		Absences: []backup.Absence{
+			{ID: "ab-1", UserID: "b", Start: at, End: at.Add(time.Hour)},
+			{ID: "ab-1", UserID: "b", Start: at, End: at.Add(time.Hour)},
+			{ID: "ab-2", UserID: "b", Start: at, End: at},
+			{ID: "ab-3", UserID: "x", Start: at, End: at.Add(time.Hour)},
+		},
+	}
+
+	r := repo.NewMemoryRepo()
+	rep, err := backup.Import(context.Background(), r, d)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	if rep.Teams != 2 || rep.Users != 2 || rep.PullRequests != 1 || rep.Pairings != 1 || rep.Absences != 1 {
+		t.Errorf("report %+v, want 2 teams, 2 users, 1 pull request, 1 pairing, 1 absence", *rep)
+	}
+	var got []string
+	for _, sk := range rep.Skipped {
+		got = append(got, sk.Kind+" "+sk.ID)
+	}
+	want := []string{
+		"user ",
+		"team backend",
+		"team bad",
+		"user a",
+		"user f",
+		"reviewer pr-1/a",
+		"reviewer pr-1/x",
+		"reviewer pr-1/b",
+		"reviewer pr-1/x",
+		"pull_request pr-1",
+		"pull_request pr-2",
+		"pull_request pr-3",
+		"pairing pr-1:a->x",
+		"pairing pr-0:a->b",
+		"absence ab-1",
+		"absence ab-2",
+		"absence ab-3",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("skipped:\n%v\nwant\n%v", got, want)
+	}
+
+	// frontend создан без пропущенных участников
+	team, err := r.GetTeam(context.Background(), "frontend")
+	if err != nil {
+		t.Fatalf("GetTeam: %v", err)
+	}
+	if len(team.Members) != 0 {
+		t.Errorf("frontend members %v, want none", team.Members)
+	}
+	pr, err := r.GetPullRequestByID(context.Background(), "pr-1")
+	if err != nil {
+		t.Fatalf("GetPullRequest: %v", err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, []string{"b"}) || pr.ShadowReviewer != "" {
+		t.Errorf("pr-1 reviewers %v shadow %q, want [b] and no shadow", pr.AssignedReviewers, pr.ShadowReviewer)
+	}
+}
+
+func TestImportNotEmpty(t *testing.T) {
+	d := export(t, populated(t))
+	target := populated(t)
+	before := export(t, target)
+
+	if _, err := backup.Import(context.Background(), target, d); !errors.Is(err, backup.ErrNotEmpty) {
+		t.Fatalf("Import into non-empty storage: err = %v, want ErrNotEmpty", err)
+	}
+	if after := export(t, target); !reflect.DeepEqual(after, before) {
+		t.Errorf("storage changed after rejected import")
+	}
+}
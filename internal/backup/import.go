@@ -0,0 +1,265 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+var ErrNotEmpty = errors.New("target storage is not empty")
+
+// виды записей в отчёте, кроме kindTeam и kindPullRequest
+const (
+	kindUser     = "user"
+	kindReviewer = "reviewer"
+)
+
+// Report — итог импорта: сколько записей загружено и какие пропущены.
+type Report struct {
+	Teams             int       `json:"teams"`
+	Users             int       `json:"users"`
+	PullRequests      int       `json:"pull_requests"`
+	AssignmentRecords int       `json:"assignment_records"` // журналы назначений загруженных PR
+	Pairings          int       `json:"pairings"`
+	Absences          int       `json:"absences"`
+	Skipped           []Skipped `json:"skipped"`
+}
+
+type Skipped struct {
+	Kind   string `json:"kind"` // team, user, pull_request, reviewer, pairing или absence
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func (rep *Report) skip(kind, id, reason string, args ...any) {
+	rep.Skipped = append(rep.Skipped, Skipped{Kind: kind, ID: id, Reason: fmt.Sprintf(reason, args...)})
+}
+
+// Import загружает дамп в пустое хранилище одной транзакцией. Записи, не
+// прошедшие проверку, пропускаются и попадают в отчёт; ошибка хранилища
+// отменяет импорт целиком.
+func Import(ctx context.Context, r repo.Repository, d *Dump) (*Report, error) {
+	var rep *Report
+
+	err := r.WithTx(ctx, func(tx repo.Repository) error {
+		rep = &Report{Skipped: []Skipped{}}
+
+		teams, err := tx.ListTeams(ctx)
+		if err != nil {
+			return err
+		}
+		prs, err := tx.ListPullRequests(ctx)
+		if err != nil {
+			return err
+		}
+		if len(teams) > 0 || len(prs) > 0 {
+			return ErrNotEmpty
+		}
+
+		users, err := importTeams(ctx, tx, d.Teams, rep)
+		if err != nil {
+			return err
+		}
+		if err := importPullRequests(ctx, tx, d.PullRequests, users, rep); err != nil {
+			return err
+		}
+		if err := importPairings(ctx, tx, d.Pairings, users, rep); err != nil {
+			return err
+		}
+		return importAbsences(ctx, tx, d.Absences, users, rep)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// importTeams создаёт команды и возвращает загруженных пользователей по id.
+func importTeams(ctx context.Context, tx repo.Repository, teams []Team, rep *Report) (map[string]string, error) {
+	seenTeams := make(map[string]bool, len(teams))
+	users := make(map[string]string) // user_id → team_name
+
+	for _, t := range teams {
+		if t.Name == "" {
+			rep.skip(kindTeam, "", "empty team_name")
+			continue
+		}
+		if seenTeams[t.Name] {
+			rep.skip(kindTeam, t.Name, "duplicate team")
+			continue
+		}
+		seenTeams[t.Name] = true
+		if err := t.Settings.Validate(); err != nil {
+			rep.skip(kindTeam, t.Name, "%v", err)
+			continue
+		}
+
+		team := &model.Team{Name: t.Name, Members: make([]model.User, 0, len(t.Members)), Settings: t.Settings}
+		for _, u := range t.Members {
+			if u.ID == "" {
+				rep.skip(kindUser, "", "empty user_id in team %q", t.Name)
+				continue
+			}
+			if other, ok := users[u.ID]; ok {
+				rep.skip(kindUser, u.ID, "already a member of team %q", other)
+				continue
+			}
+			if err := u.Settings.Validate(); err != nil {
+				rep.skip(kindUser, u.ID, "%v", err)
+				continue
+			}
+			users[u.ID] = t.Name
+			team.Members = append(team.Members, model.User{
+				ID:       u.ID,
+				Username: u.Username,
+				TeamName: t.Name,
+				IsActive: u.IsActive,
+				Settings: u.Settings,
+			})
+		}
+
+		if err := tx.CreateTeam(ctx, team); err != nil {
+			return nil, fmt.Errorf("create team %q: %w", t.Name, err)
+		}
+		rep.Teams++
+		rep.Users += len(team.Members)
+	}
+
+	return users, nil
+}
+
+func importPullRequests(ctx context.Context, tx repo.Repository, prs []PullRequest, users map[string]string, rep *Report) error {
+	seen := make(map[string]bool, len(prs))
+
+	for _, p := range prs {
+		switch {
+		case p.ID == "":
+			rep.skip(kindPullRequest, "", "empty pull_request_id")
+			continue
+		case seen[p.ID]:
+			rep.skip(kindPullRequest, p.ID, "duplicate pull request")
+			continue
+		case p.Name == "":
+			rep.skip(kindPullRequest, p.ID, "empty pull_request_name")
+			continue
+		case p.Status != string(model.PRStatusOpen) && p.Status != string(model.PRStatusMerged):
+			rep.skip(kindPullRequest, p.ID, "unknown status %q", p.Status)
+			continue
+		}
+		if _, ok := users[p.AuthorID]; !ok {
+			rep.skip(kindPullRequest, p.ID, "unknown author %q", p.AuthorID)
+			continue
+		}
+		seen[p.ID] = true
+
+		reviewers := make([]string, 0, len(p.AssignedReviewers))
+		assigned := make(map[string]bool, len(p.AssignedReviewers))
+		for _, id := range p.AssignedReviewers {
+			ref := p.ID + "/" + id
+			switch {
+			case users[id] == "":
+				rep.skip(kindReviewer, ref, "unknown user")
+			case id == p.AuthorID:
+				rep.skip(kindReviewer, ref, "author cannot review own pull request")
+			case assigned[id]:
+				rep.skip(kindReviewer, ref, "duplicate reviewer")
+			default:
+				assigned[id] = true
+				reviewers = append(reviewers, id)
+			}
+		}
+
+		shadow := p.ShadowReviewer
+		if shadow != "" && users[shadow] == "" {
+			rep.skip(kindReviewer, p.ID+"/"+shadow, "unknown shadow reviewer")
+			shadow = ""
+		}
+
+		pr := &model.PullRequest{
+			ID:                p.ID,
+			Name:              p.Name,
+			AuthorID:          p.AuthorID,
+			Status:            model.PRStatus(p.Status),
+			AssignedReviewers: reviewers,
+			ChangedPaths:      p.ChangedPaths,
+			ExcludedReviewers: p.ExcludedReviewers,
+			ShadowReviewer:    shadow,
+			CreatedAt:         p.CreatedAt,
+			MergedAt:          p.MergedAt,
+		}
+		if err := tx.CreatePullRequest(ctx, pr); err != nil {
+			return fmt.Errorf("create pull request %q: %w", p.ID, err)
+		}
+		for _, rec := range p.AssignmentLog {
+			rec.PullRequestID = p.ID
+			if err := tx.AddAssignmentRecord(ctx, rec); err != nil {
+				return fmt.Errorf("add assignment log of %q: %w", p.ID, err)
+			}
+			rep.AssignmentRecords++
+		}
+		rep.PullRequests++
+	}
+
+	return nil
+}
+
+// importPairings загружает историю назначений; пары с неизвестными
+// пользователями пропускаются.
+func importPairings(ctx context.Context, tx repo.Repository, pairings []Pairing, users map[string]string, rep *Report) error {
+	valid := make([]model.Pairing, 0, len(pairings))
+	for _, p := range pairings {
+		ref := p.PullRequestID + ":" + p.AuthorID + "->" + p.ReviewerID
+		if _, ok := users[p.AuthorID]; !ok {
+			rep.skip(kindPairing, ref, "unknown author %q", p.AuthorID)
+			continue
+		}
+		if _, ok := users[p.ReviewerID]; !ok {
+			rep.skip(kindPairing, ref, "unknown reviewer %q", p.ReviewerID)
+			continue
+		}
+		if p.AssignedAt.IsZero() {
+			rep.skip(kindPairing, ref, "empty assigned_at")
+			continue
+		}
+		valid = append(valid, model.Pairing(p))
+	}
+
+	if err := tx.AddPairings(ctx, valid); err != nil {
+		return fmt.Errorf("add pairings: %w", err)
+	}
+	rep.Pairings = len(valid)
+	return nil
+}
+
+func importAbsences(ctx context.Context, tx repo.Repository, absences []Absence, users map[string]string, rep *Report) error {
+	seen := make(map[string]bool, len(absences))
+
+	for _, a := range absences {
+		switch {
+		case a.ID == "":
+			rep.skip(kindAbsence, "", "empty absence_id")
+			continue
+		case seen[a.ID]:
+			rep.skip(kindAbsence, a.ID, "duplicate absence")
+			continue
+		case !a.End.After(a.Start):
+			rep.skip(kindAbsence, a.ID, "end is not after start")
+			continue
+		}
+		if _, ok := users[a.UserID]; !ok {
+			rep.skip(kindAbsence, a.ID, "unknown user %q", a.UserID)
+			continue
+		}
+		seen[a.ID] = true
+
+		absence := model.Absence(a)
+		if err := tx.AddAbsence(ctx, &absence); err != nil {
+			return fmt.Errorf("add absence %q: %w", a.ID, err)
+		}
+		rep.Absences++
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package codeowners разбирает правила владения путями в синтаксисе CODEOWNERS:
+//
+//	# комментарий
+//	*.go            @u1
+//	/api/           @u2 @backend/api
+//	docs/**/*.md    @backend/docs
+//
+// Владелец — @user_id или @team/group (группа из настроек команды).
+// Путь принадлежит последнему подходящему правилу; правило без владельцев
+// снимает владение.
+//
+// Шаблоны устроены как в .gitignore: * и ? не переходят через /, ** — переходит;
+// шаблон без / в начале или середине ищется на любой глубине; шаблон,
+// совпавший с каталогом, покрывает всё его содержимое.
+package codeowners
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrSyntax = errors.New("codeowners syntax error")
+
+type Rule struct {
+	Line    int
+	Pattern string
+	Owners  []string // как записаны, с @
+
+	re *regexp.Regexp
+}
+
+type Ruleset []Rule
+
+// Parse разбирает текст правил. Ошибка указывает номер строки.
+func Parse(text string) (Ruleset, error) {
+	var rules Ruleset
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		rule := Rule{Line: i + 1, Pattern: fields[0], Owners: fields[1:]}
+		for _, owner := range rule.Owners {
+			if _, _, err := ParseOwner(owner); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrSyntax, rule.Line, err)
+			}
+		}
+
+		re, err := compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrSyntax, rule.Line, err)
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseOwner разбирает владельца: @user_id или @team/group.
+func ParseOwner(owner string) (user, group string, err error) {
+	name, ok := strings.CutPrefix(owner, "@")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("owner %q must start with @", owner)
+	}
+	team, g, isGroup := strings.Cut(name, "/")
+	if !isGroup {
+		return name, "", nil
+	}
+	if team == "" || g == "" || strings.Contains(g, "/") {
+		return "", "", fmt.Errorf("owner %q: group must look like @team/group", owner)
+	}
+	return "", name, nil
+}
+
+// Match возвращает правило, которому принадлежит path, или nil.
+func (rs Ruleset) Match(path string) *Rule {
+	path = Clean(path)
+	for i := len(rs) - 1; i >= 0; i-- {
+		if rs[i].re.MatchString(path) {
+			return &rs[i]
+		}
+	}
+	return nil
+}
+
+// Clean приводит путь к виду, в котором его сравнивают с шаблонами.
+func Clean(path string) string {
+	path = strings.TrimSpace(path)
+	for {
+		switch {
+		case strings.HasPrefix(path, "./"):
+			path = path[2:]
+		case strings.HasPrefix(path, "/"):
+			path = path[1:]
+		default:
+			return path
+		}
+	}
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	p := pattern
+	anchored := strings.HasPrefix(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern %q", pattern)
+	}
+	if strings.Contains(p, "/") {
+		anchored = true
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	// совпадение с каталогом покрывает всё внутри него
+	b.WriteString("(?:/.*)?$")
+
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,140 @@
+package codeowners_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/codeowners"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		path  string
+		want  []string // nil — путь без владельцев
+	}{
+		{"unanchored name at root", "*.go @u1", "main.go", []string{"@u1"}},
+		{"unanchored name in subdir", "*.go @u1", "internal/repo/sql.go", []string{"@u1"}},
+		{"star does not cross slash", "internal/*.go @u1", "internal/repo/sql.go", nil},
+		{"anchored at root", "/api @u1", "api/handler.go", []string{"@u1"}},
+		{"anchored not in subdir", "/api @u1", "internal/api/handler.go", nil},
+		{"slash in middle anchors", "internal/api @u1", "cmd/internal/api/x.go", nil},
+		{"unanchored dir at any depth", "api/ @u1", "internal/api/handler.go", []string{"@u1"}},
+		{"directory covers contents", "/docs/ @u1", "docs/a/b/c.md", []string{"@u1"}},
+		{"directory prefix is not a match", "/doc @u1", "docs/a.md", nil},
+		{"double star any depth", "docs/**/*.md @u1", "docs/a/b/c.md", []string{"@u1"}},
+		{"double star zero dirs", "docs/**/*.md @u1", "docs/c.md", []string{"@u1"}},
+		{"trailing double star", "/vendor/** @u1", "vendor/x/y.go", []string{"@u1"}},
+		{"question mark", "v?.txt @u1", "v1.txt", []string{"@u1"}},
+		{"question mark not slash", "a?b @u1", "a/b", nil},
+		{"leading dot slash in path", "/api @u1", "./api/x.go", []string{"@u1"}},
+		{
+			"last match wins",
+			"* @u1\n*.go @u2\n/internal/ @u3",
+			"internal/x.go",
+			[]string{"@u3"},
+		},
+		{
+			"earlier rule when later does not match",
+			"* @u1\n*.md @u2",
+			"main.go",
+			[]string{"@u1"},
+		},
+		{
+			"rule without owners drops ownership",
+			"* @u1\n/generated/",
+			"generated/api.go",
+			[]string{},
+		},
+		{
+			"comments and blank lines",
+			"# owners\n\n   \n*.go @u1 # backend\n# *.go @u2\n",
+			"main.go",
+			[]string{"@u1"},
+		},
+		{"groups", "*.go @u1 @backend/api", "main.go", []string{"@u1", "@backend/api"}},
+		{"no rules", "", "main.go", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := codeowners.Parse(tt.rules)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			rule := rs.Match(tt.path)
+			if tt.want == nil {
+				if rule != nil {
+					t.Fatalf("Match(%q) = rule on line %d, want none", tt.path, rule.Line)
+				}
+				return
+			}
+			if rule == nil {
+				t.Fatalf("Match(%q) = none, want %v", tt.path, tt.want)
+			}
+			if !slices.Equal(rule.Owners, tt.want) {
+				t.Errorf("Match(%q).Owners = %v, want %v", tt.path, rule.Owners, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	rs, err := codeowners.Parse("# header\n\n*.go @u1\n  /api/ @u2  \n")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(rs) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rs))
+	}
+	if rs[0].Line != 3 || rs[0].Pattern != "*.go" {
+		t.Errorf("rule 0 = line %d %q, want line 3 \"*.go\"", rs[0].Line, rs[0].Pattern)
+	}
+	if rs[1].Line != 4 || rs[1].Pattern != "/api/" {
+		t.Errorf("rule 1 = line %d %q, want line 4 \"/api/\"", rs[1].Line, rs[1].Pattern)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{"owner without at", "*.go u1"},
+		{"empty owner", "*.go @"},
+		{"group without name", "*.go @backend/"},
+		{"group without team", "*.go @/api"},
+		{"nested group", "*.go @backend/api/x"},
+		{"empty pattern", "/ @u1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := codeowners.Parse("*.md @u1\n" + tt.rules)
+			if !errors.Is(err, codeowners.ErrSyntax) {
+				t.Fatalf("Parse(%q) = %v, want ErrSyntax", tt.rules, err)
+			}
+		})
+	}
+}
+
+func TestParseOwner(t *testing.T) {
+	tests := []struct {
+		owner       string
+		user, group string
+	}{
+		{"@u1", "u1", ""},
+		{"@backend/api", "", "backend/api"},
+	}
+	for _, tt := range tests {
+		user, group, err := codeowners.ParseOwner(tt.owner)
+		if err != nil {
+			t.Errorf("ParseOwner(%q): %v", tt.owner, err)
+			continue
+		}
+		if user != tt.user || group != tt.group {
+			t.Errorf("ParseOwner(%q) = %q, %q, want %q, %q", tt.owner, user, group, tt.user, tt.group)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+type AbsenceDTO struct {
+	AbsenceID string `json:"absence_id"`
+	UserID    string `json:"user_id"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func newAbsenceDTO(a model.Absence) AbsenceDTO {
+	return AbsenceDTO{
+		AbsenceID: a.ID,
+		UserID:    a.UserID,
+		Start:     a.Start.Format(time.RFC3339),
+		End:       a.End.Format(time.RFC3339),
+		Reason:    a.Reason,
+	}
+}
+
+// GET /users/absences — отсутствия пользователя.
+func (h *Handler) ListAbsences(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	absences, err := h.svc.ListAbsences(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NOT_FOUND",
+					"message": "resource not found",
+				},
+			})
+			return
+		}
+
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	dtos := make([]AbsenceDTO, 0, len(absences))
+	for _, a := range absences {
+		dtos = append(dtos, newAbsenceDTO(a))
+	}
+	resp := map[string]any{
+		"user_id":  userID,
+		"absences": dtos,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type AddAbsenceRequest struct {
+	UserID string `json:"user_id"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Reason string `json:"reason"`
+}
+
+// POST /users/absences/add — регистрирует отсутствие; start и end в RFC 3339.
+func (h *Handler) AddAbsence(w http.ResponseWriter, r *http.Request) {
+	var req AddAbsenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if req.UserID == "" || req.Start == "" || req.End == "" {
+		http.Error(w, "user_id, start and end are required", http.StatusBadRequest)
+		return
+	}
+	start, err := time.Parse(time.RFC3339, req.Start)
+	if err != nil {
+		http.Error(w, "start must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse(time.RFC3339, req.End)
+	if err != nil {
+		http.Error(w, "end must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	absence, err := h.svc.AddAbsence(r.Context(), req.UserID, start, end, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NOT_FOUND",
+					"message": "resource not found",
+				},
+			})
+			return
+		case errors.Is(err, service.ErrAbsenceExists):
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "ABSENCE_EXISTS",
+					"message": "absence_id already exists, retry the request",
+				},
+			})
+			return
+		case errors.Is(err, service.ErrInvalidAbsence):
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "INVALID_ABSENCE",
+					"message": err.Error(),
+				},
+			})
+			return
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resp := map[string]any{
+		"absence": newAbsenceDTO(*absence),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type CancelAbsenceRequest struct {
+	AbsenceID string `json:"absence_id"`
+}
+
+// POST /users/absences/cancel — отменяет отсутствие.
+func (h *Handler) CancelAbsence(w http.ResponseWriter, r *http.Request) {
+	var req CancelAbsenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if req.AbsenceID == "" {
+		http.Error(w, "absence_id is required", http.StatusBadRequest)
+		return
+	}
+
+	absence, err := h.svc.CancelAbsence(r.Context(), req.AbsenceID)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NOT_FOUND",
+					"message": "resource not found",
+				},
+			})
+			return
+		}
+
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]any{
+		"absence": newAbsenceDTO(*absence),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,93 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/backup"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+// GET /admin/export?format=json|ndjson
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "ndjson" {
+		http.Error(w, "format must be json or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	dump, err := h.svc.Export(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "ndjson" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		_ = backup.WriteNDJSON(w, dump)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = backup.WriteJSON(w, dump)
+}
+
+// maxImportSize — наибольший размер тела /admin/import.
+const maxImportSize = 64 << 20
+
+// POST /admin/import — тело в формате JSON или NDJSON, как отдаёт /admin/export.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	report, err := h.svc.Import(r.Context(), body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "DUMP_TOO_LARGE",
+					"message": fmt.Sprintf("dump must not exceed %d bytes", tooLarge.Limit),
+				},
+			})
+			return
+		}
+		if errors.Is(err, service.ErrBadDump) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "INVALID_DUMP",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
+		if errors.Is(err, service.ErrStoreNotEmpty) {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "STORE_NOT_EMPTY",
+					"message": "import is only allowed into an empty storage",
+				},
+			})
+			return
+		}
+
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]any{
+		"report": report,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
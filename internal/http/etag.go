@@ -0,0 +1,36 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Версия PR/команды отдаётся как сильный ETag вида "3".
+// Мутирующие запросы могут передать её в If-Match, чтобы не перетереть
+// чужое изменение: при несовпадении вернётся 412.
+
+var errBadIfMatch = errors.New("invalid If-Match header")
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// parseIfMatch возвращает ожидаемую версию из If-Match.
+// 0 — заголовка нет или он равен "*", т.е. проверять версию не нужно.
+func parseIfMatch(r *http.Request) (int64, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return 0, errBadIfMatch
+	}
+	version, err := strconv.ParseInt(v[1:len(v)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errBadIfMatch
+	}
+	return version, nil
+}
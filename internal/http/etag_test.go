@@ -0,0 +1,190 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	httpapi "github.com/iamyblitz/pr-reviewer-service/internal/http"
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+// newServer создаёт роутер на пустом MemoryRepo с командой backend
+// и открытым PR pr-1 от a.
+func newServer(t *testing.T) http.Handler {
+	t.Helper()
+	ctx := context.Background()
+	svc := service.NewService(repo.NewMemoryRepo())
+	members := []model.User{
+		{ID: "a", Username: "a", IsActive: true},
+		{ID: "b", Username: "b", IsActive: true},
+		{ID: "c", Username: "c", IsActive: true},
+	}
+	if _, err := svc.CreateTeam(ctx, "backend", members, model.TeamSettings{ReviewerCount: 1}); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if _, err := svc.CreatePullRequest(ctx, "pr-1", "change", "a", service.CreatePROptions{}); err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	return httpapi.NewRouter(svc)
+}
+
+// do выполняет запрос к h; ifMatch "" — без заголовка If-Match.
+func do(h http.Handler, method, target, body, ifMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// errorCode возвращает error.code из JSON-ответа с ошибкой.
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error response %q: %v", rec.Body.String(), err)
+	}
+	return resp.Error.Code
+}
+
+var etagFormat = regexp.MustCompile(`^"[1-9][0-9]*"$`)
+
+func TestETag(t *testing.T) {
+	h := newServer(t)
+
+	rec := do(h, http.MethodGet, "/pullRequest/get?pull_request_id=pr-1", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET pr: status %d", rec.Code)
+	}
+	etag := rec.Header().Get("ETag")
+	if !etagFormat.MatchString(etag) {
+		t.Fatalf("ETag %q, want a quoted version", etag)
+	}
+
+	rec = do(h, http.MethodGet, "/team/get?team_name=backend", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET team: status %d", rec.Code)
+	}
+	if etag := rec.Header().Get("ETag"); !etagFormat.MatchString(etag) {
+		t.Errorf("team ETag %q, want a quoted version", etag)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	const (
+		merge    = `{"pull_request_id":"pr-1"}`
+		settings = `{"team_name":"backend","settings":{"reviewer_count":2}}`
+	)
+	tests := []struct {
+		name    string
+		target  string
+		body    string
+		get     string                   // откуда взять текущий ETag
+		ifMatch func(etag string) string // значение If-Match по текущему ETag
+		status  int
+		code    string // error.code; "" — не проверять
+	}{
+		{"merge match", "/pullRequest/merge", merge, "/pullRequest/get?pull_request_id=pr-1",
+			func(etag string) string { return etag }, http.StatusOK, ""},
+		{"merge mismatch", "/pullRequest/merge", merge, "/pullRequest/get?pull_request_id=pr-1",
+			func(string) string { return `"999"` }, http.StatusPreconditionFailed, "VERSION_CONFLICT"},
+		{"merge missing header", "/pullRequest/merge", merge, "/pullRequest/get?pull_request_id=pr-1",
+			func(string) string { return "" }, http.StatusOK, ""},
+		{"merge any version", "/pullRequest/merge", merge, "/pullRequest/get?pull_request_id=pr-1",
+			func(string) string { return "*" }, http.StatusOK, ""},
+		{"merge unquoted", "/pullRequest/merge", merge, "/pullRequest/get?pull_request_id=pr-1",
+			func(etag string) string { return strings.Trim(etag, `"`) }, http.StatusBadRequest, ""},
+		{"merge weak", "/pullRequest/merge", merge, "/pullRequest/get?pull_request_id=pr-1",
+			func(etag string) string { return "W/" + etag }, http.StatusBadRequest, ""},
+		{"merge not a number", "/pullRequest/merge", merge, "/pullRequest/get?pull_request_id=pr-1",
+			func(string) string { return `"abc"` }, http.StatusBadRequest, ""},
+		{"merge zero", "/pullRequest/merge", merge, "/pullRequest/get?pull_request_id=pr-1",
+			func(string) string { return `"0"` }, http.StatusBadRequest, ""},
+		{"settings match", "/team/settings", settings, "/team/get?team_name=backend",
+			func(etag string) string { return etag }, http.StatusOK, ""},
+		{"settings mismatch", "/team/settings", settings, "/team/get?team_name=backend",
+			func(string) string { return `"999"` }, http.StatusPreconditionFailed, "VERSION_CONFLICT"},
+		{"settings missing header", "/team/settings", settings, "/team/get?team_name=backend",
+			func(string) string { return "" }, http.StatusOK, ""},
+		{"settings malformed", "/team/settings", settings, "/team/get?team_name=backend",
+			func(string) string { return `"1` }, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newServer(t)
+			etag := do(h, http.MethodGet, tt.get, "", "").Header().Get("ETag")
+
+			rec := do(h, http.MethodPost, tt.target, tt.body, tt.ifMatch(etag))
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.code != "" {
+				if code := errorCode(t, rec); code != tt.code {
+					t.Errorf("error code %q, want %q", code, tt.code)
+				}
+			}
+			if rec.Code != http.StatusOK {
+				// запрос отклонён — версия не должна измениться
+				if after := do(h, http.MethodGet, tt.get, "", "").Header().Get("ETag"); after != etag {
+					t.Errorf("ETag changed from %s to %s after rejected request", etag, after)
+				}
+				return
+			}
+			after := rec.Header().Get("ETag")
+			if !etagFormat.MatchString(after) || after == etag {
+				t.Errorf("ETag after update %q, want a new quoted version (was %s)", after, etag)
+			}
+		})
+	}
+}
+
+func TestIfMatchReassign(t *testing.T) {
+	h := newServer(t)
+	rec := do(h, http.MethodGet, "/pullRequest/get?pull_request_id=pr-1", "", "")
+	etag := rec.Header().Get("ETag")
+	var resp struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode pr: %v", err)
+	}
+	body := `{"pull_request_id":"pr-1","old_user_id":"` + resp.PR.AssignedReviewers[0] + `"}`
+
+	version, err := strconv.ParseInt(strings.Trim(etag, `"`), 10, 64)
+	if err != nil {
+		t.Fatalf("ETag %q: %v", etag, err)
+	}
+	stale := `"` + strconv.FormatInt(version+1, 10) + `"`
+	rec = do(h, http.MethodPost, "/pullRequest/reassign", body, stale)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: status %d, want 412: %s", rec.Code, rec.Body)
+	}
+	if code := errorCode(t, rec); code != "VERSION_CONFLICT" {
+		t.Errorf("error code %q, want VERSION_CONFLICT", code)
+	}
+
+	rec = do(h, http.MethodPost, "/pullRequest/reassign", body, etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("matching If-Match: status %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if after := rec.Header().Get("ETag"); !etagFormat.MatchString(after) || after == etag {
+		t.Errorf("ETag after reassign %q, want a new quoted version (was %s)", after, etag)
+	}
+}
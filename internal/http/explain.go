@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+type AssignmentRecordDTO struct {
+	Action         string             `json:"action"`
+	ReplacedUserID string             `json:"replaced_user_id,omitempty"`
+	Assigned       []string           `json:"assigned"`
+	Steps          []SelectionStepDTO `json:"steps"`
+	CreatedAt      string             `json:"createdAt"`
+}
+
+type SelectionStepDTO struct {
+	Purpose  string         `json:"purpose"`
+	TeamName string         `json:"team_name"`
+	Strategy string         `json:"strategy,omitempty"` // пусто для обязательных ревьюверов
+	Count    int            `json:"count"`
+	Pool     []string       `json:"pool"`
+	Excluded []ExclusionDTO `json:"excluded"`
+	OffHours []string       `json:"off_hours,omitempty"`
+	Chosen   []string       `json:"chosen"`
+}
+
+type ExclusionDTO struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+func newAssignmentRecordDTO(rec model.AssignmentRecord) AssignmentRecordDTO {
+	dto := AssignmentRecordDTO{
+		Action:         rec.Action,
+		ReplacedUserID: rec.ReplacedUserID,
+		Assigned:       rec.Assigned,
+		Steps:          make([]SelectionStepDTO, 0, len(rec.Steps)),
+		CreatedAt:      rec.CreatedAt.Format(time.RFC3339),
+	}
+	for _, st := range rec.Steps {
+		step := SelectionStepDTO{
+			Purpose:  st.Purpose,
+			TeamName: st.Team,
+			Strategy: st.Strategy,
+			Count:    st.Count,
+			Pool:     st.Pool,
+			Excluded: make([]ExclusionDTO, 0, len(st.Excluded)),
+			OffHours: st.OffHours,
+			Chosen:   st.Chosen,
+		}
+		for _, ex := range st.Excluded {
+			step.Excluded = append(step.Excluded, ExclusionDTO{UserID: ex.UserID, Reason: ex.Reason})
+		}
+		dto.Steps = append(dto.Steps, step)
+	}
+	return dto
+}
+
+// GET /pullRequest/explain — как назначались ревьюверы PR.
+func (h *Handler) ExplainPullRequest(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	records, err := h.svc.ExplainPullRequest(r.Context(), prID)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NOT_FOUND",
+					"message": "resource not found",
+				},
+			})
+			return
+		}
+
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	assignments := make([]AssignmentRecordDTO, 0, len(records))
+	for _, rec := range records {
+		assignments = append(assignments, newAssignmentRecordDTO(rec))
+	}
+	resp := map[string]any{
+		"pull_request_id": prID,
+		"assignments":     assignments,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
 	"github.com/iamyblitz/pr-reviewer-service/internal/service"
 )
 
@@ -13,6 +14,13 @@ type CreatePRRequest struct {
 	PullRequestID   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+	// ReviewerCount — сколько ревьюверов назначить; не задано — по настройкам команды.
+	ReviewerCount int `json:"reviewer_count,omitempty"`
+	// ChangedPaths — файлы, которые затрагивает PR, для назначения владельцев.
+	ChangedPaths []string `json:"changed_paths,omitempty"`
+	// RequiredReviewers — кого назначить обязательно, ExcludedReviewers — кого не назначать.
+	RequiredReviewers []string `json:"required_reviewers,omitempty"`
+	ExcludedReviewers []string `json:"excluded_reviewers,omitempty"`
 }
 
 type PullRequestDTO struct {
@@ -21,8 +29,39 @@ type PullRequestDTO struct {
 	AuthorID          string   `json:"author_id"`
 	Status            string   `json:"status"`
 	AssignedReviewers []string `json:"assigned_reviewers"`
-	CreatedAt         *string  `json:"createdAt,omitempty"`
-	MergedAt          *string  `json:"mergedAt,omitempty"`
+	ChangedPaths      []string `json:"changed_paths,omitempty"`
+	ExcludedReviewers []string `json:"excluded_reviewers,omitempty"`
+	// ShadowReviewer — подопечный, который смотрит ревью, не входя в assigned_reviewers.
+	ShadowReviewer string  `json:"shadow_reviewer,omitempty"`
+	CreatedAt      *string `json:"createdAt,omitempty"`
+	MergedAt       *string `json:"mergedAt,omitempty"`
+}
+
+func newPullRequestDTO(pr *model.PullRequest) PullRequestDTO {
+	var createdAtStr *string
+	if pr.CreatedAt != nil {
+		s := pr.CreatedAt.Format(time.RFC3339)
+		createdAtStr = &s
+	}
+
+	var mergedAtStr *string
+	if pr.MergedAt != nil {
+		s := pr.MergedAt.Format(time.RFC3339)
+		mergedAtStr = &s
+	}
+
+	return PullRequestDTO{
+		ID:                pr.ID,
+		Name:              pr.Name,
+		AuthorID:          pr.AuthorID,
+		Status:            string(pr.Status),
+		AssignedReviewers: pr.AssignedReviewers,
+		ChangedPaths:      pr.ChangedPaths,
+		ExcludedReviewers: pr.ExcludedReviewers,
+		ShadowReviewer:    pr.ShadowReviewer,
+		CreatedAt:         createdAtStr,
+		MergedAt:          mergedAtStr,
+	}
 }
 
 func (h *Handler) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
@@ -36,8 +75,18 @@ func (h *Handler) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "pull_request_id, pull_request_name and author_id are required", http.StatusBadRequest)
 		return
 	}
+	if req.ReviewerCount < 0 {
+		http.Error(w, "reviewer_count must be positive", http.StatusBadRequest)
+		return
+	}
 
-	pr, err := h.svc.CreatePullRequest(req.PullRequestID, req.PullRequestName, req.AuthorID)
+	res, err := h.svc.CreatePullRequest(r.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID,
+		service.CreatePROptions{
+			ReviewerCount:     req.ReviewerCount,
+			ChangedPaths:      req.ChangedPaths,
+			RequiredReviewers: req.RequiredReviewers,
+			ExcludedReviewers: req.ExcludedReviewers,
+		})
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
@@ -59,37 +108,109 @@ func (h *Handler) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, service.ErrAtCapacity) {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "AT_CAPACITY",
+					"message": "all candidates already have the maximum number of open reviews",
+				},
+			})
+			return
+		}
+		if errors.Is(err, service.ErrNoSenior) {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NO_SENIOR",
+					"message": "team requires a senior reviewer, but none is available",
+				},
+			})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidReviewerCount) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "INVALID_REVIEWER_COUNT",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidReviewers) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "INVALID_REVIEWERS",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
 
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	var createdAtStr *string
-	if pr.CreatedAt != nil {
-		s := pr.CreatedAt.Format(time.RFC3339)
-		createdAtStr = &s
+	pr := res.PR
+	assignments := make([]AssignmentDTO, 0, len(res.Assignments))
+	for _, a := range res.Assignments {
+		assignments = append(assignments, newAssignmentDTO(a))
+	}
+	resp := map[string]any{
+		"pr":                  newPullRequestDTO(pr),
+		"assignments":         assignments,
+		"requested_reviewers": res.RequestedReviewers,
+		"missing_reviewers":   res.MissingReviewers(),
+	}
+	if len(res.UncoveredPaths) > 0 {
+		resp["uncovered_paths"] = res.UncoveredPaths
+	}
+	if len(res.AtCapacity) > 0 {
+		resp["at_capacity"] = res.AtCapacity
+	}
+	if len(res.RequiredNotAssigned) > 0 {
+		resp["required_not_assigned"] = res.RequiredNotAssigned
 	}
 
-	var mergedAtStr *string
-	if pr.MergedAt != nil {
-		s := pr.MergedAt.Format(time.RFC3339)
-		mergedAtStr = &s
+	w.Header().Set("Content-Type", "application/json")
+	setETag(w, pr.Version)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) GetPullRequest(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := h.svc.GetPullRequest(r.Context(), prID)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NOT_FOUND",
+					"message": "resource not found",
+				},
+			})
+			return
+		}
+
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
 	}
 
 	resp := map[string]any{
-		"pr": PullRequestDTO{
-			ID:                pr.ID,
-			Name:              pr.Name,
-			AuthorID:          pr.AuthorID,
-			Status:            string(pr.Status),
-			AssignedReviewers: pr.AssignedReviewers,
-			CreatedAt:         createdAtStr,
-			MergedAt:          mergedAtStr,
-		},
+		"pr": newPullRequestDTO(pr),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	setETag(w, pr.Version)
+	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
@@ -109,7 +230,13 @@ func (h *Handler) MergePullRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.svc.MergePullRequest(req.PullRequestID)
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pr, err := h.svc.MergePullRequest(r.Context(), req.PullRequestID, expectedVersion)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
@@ -121,36 +248,27 @@ func (h *Handler) MergePullRequest(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, service.ErrVersionConflict) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "VERSION_CONFLICT",
+					"message": "PR was modified, reload and retry",
+				},
+			})
+			return
+		}
 
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	var createdAtStr *string
-	if pr.CreatedAt != nil {
-		s := pr.CreatedAt.Format(time.RFC3339)
-		createdAtStr = &s
-	}
-
-	var mergedAtStr *string
-	if pr.MergedAt != nil {
-		s := pr.MergedAt.Format(time.RFC3339)
-		mergedAtStr = &s
-	}
-
 	resp := map[string]any{
-		"pr": PullRequestDTO{
-			ID:                pr.ID,
-			Name:              pr.Name,
-			AuthorID:          pr.AuthorID,
-			Status:            string(pr.Status),
-			AssignedReviewers: pr.AssignedReviewers,
-			CreatedAt:         createdAtStr,
-			MergedAt:          mergedAtStr,
-		},
+		"pr": newPullRequestDTO(pr),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, pr.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -163,6 +281,18 @@ type ReassignPRRequest struct {
 type ReassignPRResponse struct {
 	PR         PullRequestDTO `json:"pr"`
 	ReplacedBy string         `json:"replaced_by"`
+	Assignment AssignmentDTO  `json:"assignment"`
+}
+
+// AssignmentDTO — назначенный ревьювер; cross_team — он не из команды автора.
+type AssignmentDTO struct {
+	UserID    string `json:"user_id"`
+	TeamName  string `json:"team_name"`
+	CrossTeam bool   `json:"cross_team"`
+}
+
+func newAssignmentDTO(a service.Assignment) AssignmentDTO {
+	return AssignmentDTO{UserID: a.UserID, TeamName: a.TeamName, CrossTeam: a.CrossTeam}
 }
 
 func (h *Handler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
@@ -177,7 +307,13 @@ func (h *Handler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, replacedBy, err := h.svc.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.svc.ReassignReviewer(r.Context(), req.PullRequestID, req.OldUserID, expectedVersion)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNotFound):
@@ -207,12 +343,39 @@ func (h *Handler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 				},
 			})
 			return
+		case errors.Is(err, service.ErrVersionConflict):
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "VERSION_CONFLICT",
+					"message": "PR was modified, reload and retry",
+				},
+			})
+			return
+		case errors.Is(err, service.ErrAtCapacity):
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "AT_CAPACITY",
+					"message": "all candidates already have the maximum number of open reviews",
+				},
+			})
+			return
+		case errors.Is(err, service.ErrNoSenior):
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NO_SENIOR",
+					"message": "team requires a senior reviewer, but none is available",
+				},
+			})
+			return
 		case errors.Is(err, service.ErrNoCandidate):
 			w.WriteHeader(http.StatusConflict)
 			_ = json.NewEncoder(w).Encode(map[string]any{
 				"error": map[string]any{
 					"code":    "NO_CANDIDATE",
-					"message": "no active replacement candidate in team",
+					"message": "no active replacement candidate in team or its fallback teams",
 				},
 			})
 			return
@@ -222,32 +385,15 @@ func (h *Handler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	var createdAtStr *string
-	if pr.CreatedAt != nil {
-		s := pr.CreatedAt.Format(time.RFC3339)
-		createdAtStr = &s
-	}
-
-	var mergedAtStr *string
-	if pr.MergedAt != nil {
-		s := pr.MergedAt.Format(time.RFC3339)
-		mergedAtStr = &s
-	}
-
+	pr := res.PR
 	resp := ReassignPRResponse{
-		PR: PullRequestDTO{
-			ID:                pr.ID,
-			Name:              pr.Name,
-			AuthorID:          pr.AuthorID,
-			Status:            string(pr.Status),
-			AssignedReviewers: pr.AssignedReviewers,
-			CreatedAt:         createdAtStr,
-			MergedAt:          mergedAtStr,
-		},
-		ReplacedBy: replacedBy,
+		PR:         newPullRequestDTO(pr),
+		ReplacedBy: res.Replacement.UserID,
+		Assignment: newAssignmentDTO(res.Replacement),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, pr.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -31,6 +31,14 @@ func NewRouter(svc *service.Service) http.Handler {
 		h.GetTeam(w, r)
 	})
 
+	mux.HandleFunc("/team/settings", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.SetTeamSettings(w, r)
+	})
+
 	mux.HandleFunc("/users/setIsActive", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -39,6 +47,38 @@ func NewRouter(svc *service.Service) http.Handler {
 		h.SetUserIsActive(w, r)
 	})
 
+	mux.HandleFunc("/users/settings", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.SetUserSettings(w, r)
+	})
+
+	mux.HandleFunc("/users/absences", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.ListAbsences(w, r)
+	})
+
+	mux.HandleFunc("/users/absences/add", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.AddAbsence(w, r)
+	})
+
+	mux.HandleFunc("/users/absences/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.CancelAbsence(w, r)
+	})
+
 	mux.HandleFunc("/pullRequest/create", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -47,6 +87,14 @@ func NewRouter(svc *service.Service) http.Handler {
 		h.CreatePullRequest(w, r)
 	})
 
+	mux.HandleFunc("/pullRequest/get", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetPullRequest(w, r)
+	})
+
 	mux.HandleFunc("/pullRequest/merge", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -71,5 +119,29 @@ func NewRouter(svc *service.Service) http.Handler {
 		h.ReassignReviewer(w, r)
 	})
 
+	mux.HandleFunc("/pullRequest/explain", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.ExplainPullRequest(w, r)
+	})
+
+	mux.HandleFunc("/admin/export", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.Export(w, r)
+	})
+
+	mux.HandleFunc("/admin/import", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		h.Import(w, r)
+	})
+
 	return mux
 }
@@ -10,14 +10,34 @@ import (
 )
 
 type TeamMemberDTO struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID   string          `json:"user_id"`
+	Username string          `json:"username"`
+	IsActive bool            `json:"is_active"`
+	Settings UserSettingsDTO `json:"settings"`
 }
 
 type TeamDTO struct {
 	TeamName string          `json:"team_name"`
 	Members  []TeamMemberDTO `json:"members"`
+	Settings TeamSettingsDTO `json:"settings"`
+}
+
+func newTeamDTO(team *model.Team) TeamDTO {
+	members := make([]TeamMemberDTO, 0, len(team.Members))
+	for _, m := range team.Members {
+		members = append(members, TeamMemberDTO{
+			UserID:   m.ID,
+			Username: m.Username,
+			IsActive: m.IsActive,
+			Settings: newUserSettingsDTO(m.Settings),
+		})
+	}
+
+	return TeamDTO{
+		TeamName: team.Name,
+		Members:  members,
+		Settings: newTeamSettingsDTO(team.Settings),
+	}
 }
 
 type Handler struct {
@@ -43,10 +63,11 @@ func (h *Handler) AddTeam(w http.ResponseWriter, r *http.Request) {
 			Username: m.Username,
 			TeamName: dto.TeamName,
 			IsActive: m.IsActive,
+			Settings: m.Settings.toModel(),
 		})
 	}
 
-	team, err := h.svc.CreateTeam(dto.TeamName, members)
+	team, err := h.svc.CreateTeam(r.Context(), dto.TeamName, members, dto.Settings.toModel())
 	if err != nil {
 		if errors.Is(err, service.ErrTeamExists) {
 			w.WriteHeader(http.StatusBadRequest)
@@ -58,24 +79,22 @@ func (h *Handler) AddTeam(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, service.ErrInvalidSettings) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "INVALID_SETTINGS",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	respMembers := make([]TeamMemberDTO, 0, len(team.Members))
-	for _, m := range team.Members {
-		respMembers = append(respMembers, TeamMemberDTO{
-			UserID:   m.ID,
-			Username: m.Username,
-			IsActive: m.IsActive,
-		})
-	}
-
 	resp := map[string]any{
-		"team": TeamDTO{
-			TeamName: team.Name,
-			Members:  respMembers,
-		},
+		"team": newTeamDTO(team),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -90,7 +109,7 @@ func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	team, err := h.svc.GetTeam(teamName)
+	team, err := h.svc.GetTeam(r.Context(), teamName)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
@@ -107,21 +126,10 @@ func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	members := make([]TeamMemberDTO, 0, len(team.Members))
-	for _, m := range team.Members {
-		members = append(members, TeamMemberDTO{
-			UserID:   m.ID,
-			Username: m.Username,
-			IsActive: m.IsActive,
-		})
-	}
-
-	resp := TeamDTO{
-		TeamName: team.Name,
-		Members:  members,
-	}
+	resp := newTeamDTO(team)
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, team.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -0,0 +1,132 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+type TeamSettingsDTO struct {
+	ReviewerStrategy string `json:"reviewer_strategy,omitempty"`
+	ReviewerCount    int    `json:"reviewer_count,omitempty"`
+	MinReviewers     int    `json:"min_reviewers,omitempty"`
+	MaxReviewers     int    `json:"max_reviewers,omitempty"`
+
+	CodeOwners  string              `json:"codeowners,omitempty"`
+	OwnerGroups map[string][]string `json:"owner_groups,omitempty"`
+
+	FallbackTeams []string `json:"fallback_teams,omitempty"`
+
+	MaxOpenReviews int `json:"max_open_reviews,omitempty"`
+
+	PairingWindowDays int `json:"pairing_window_days,omitempty"`
+
+	RequireSenior bool `json:"require_senior,omitempty"`
+
+	Mentees []string `json:"mentees,omitempty"`
+}
+
+func newTeamSettingsDTO(s model.TeamSettings) TeamSettingsDTO {
+	return TeamSettingsDTO{
+		ReviewerStrategy:  s.ReviewerStrategy,
+		ReviewerCount:     s.ReviewerCount,
+		MinReviewers:      s.MinReviewers,
+		MaxReviewers:      s.MaxReviewers,
+		CodeOwners:        s.CodeOwners,
+		OwnerGroups:       s.OwnerGroups,
+		FallbackTeams:     s.FallbackTeams,
+		MaxOpenReviews:    s.MaxOpenReviews,
+		PairingWindowDays: s.PairingWindowDays,
+		RequireSenior:     s.RequireSenior,
+		Mentees:           s.Mentees,
+	}
+}
+
+func (dto TeamSettingsDTO) toModel() model.TeamSettings {
+	return model.TeamSettings{
+		ReviewerStrategy:  dto.ReviewerStrategy,
+		ReviewerCount:     dto.ReviewerCount,
+		MinReviewers:      dto.MinReviewers,
+		MaxReviewers:      dto.MaxReviewers,
+		CodeOwners:        dto.CodeOwners,
+		OwnerGroups:       dto.OwnerGroups,
+		FallbackTeams:     dto.FallbackTeams,
+		MaxOpenReviews:    dto.MaxOpenReviews,
+		PairingWindowDays: dto.PairingWindowDays,
+		RequireSenior:     dto.RequireSenior,
+		Mentees:           dto.Mentees,
+	}
+}
+
+type SetTeamSettingsRequest struct {
+	TeamName string          `json:"team_name"`
+	Settings TeamSettingsDTO `json:"settings"`
+}
+
+// POST /team/settings — заменяет настройки команды целиком.
+func (h *Handler) SetTeamSettings(w http.ResponseWriter, r *http.Request) {
+	var req SetTeamSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if req.TeamName == "" {
+		http.Error(w, "team_name is required", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.svc.SetTeamSettings(r.Context(), req.TeamName, req.Settings.toModel(), expectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NOT_FOUND",
+					"message": "resource not found",
+				},
+			})
+			return
+		case errors.Is(err, service.ErrInvalidSettings):
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "INVALID_SETTINGS",
+					"message": err.Error(),
+				},
+			})
+			return
+		case errors.Is(err, service.ErrVersionConflict):
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "VERSION_CONFLICT",
+					"message": "team was modified, reload and retry",
+				},
+			})
+			return
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resp := map[string]any{
+		"team": newTeamDTO(team),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setETag(w, team.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
 	"github.com/iamyblitz/pr-reviewer-service/internal/service"
 )
 
@@ -14,10 +15,55 @@ type SetIsActiveRequest struct {
 }
 
 type UserDTO struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID   string          `json:"user_id"`
+	Username string          `json:"username"`
+	TeamName string          `json:"team_name"`
+	IsActive bool            `json:"is_active"`
+	Settings UserSettingsDTO `json:"settings"`
+}
+
+func newUserDTO(user *model.User) UserDTO {
+	return UserDTO{
+		UserID:   user.ID,
+		Username: user.Username,
+		TeamName: user.TeamName,
+		IsActive: user.IsActive,
+		Settings: newUserSettingsDTO(user.Settings),
+	}
+}
+
+type UserSettingsDTO struct {
+	MaxOpenReviews    int      `json:"max_open_reviews,omitempty"`
+	Level             string   `json:"level,omitempty"`
+	Timezone          string   `json:"timezone,omitempty"`
+	WorkStart         string   `json:"work_start,omitempty"`
+	WorkEnd           string   `json:"work_end,omitempty"`
+	RequiredReviewers []string `json:"required_reviewers,omitempty"`
+	ExcludedReviewers []string `json:"excluded_reviewers,omitempty"`
+}
+
+func newUserSettingsDTO(s model.UserSettings) UserSettingsDTO {
+	return UserSettingsDTO{
+		MaxOpenReviews:    s.MaxOpenReviews,
+		Level:             string(s.Level),
+		Timezone:          s.Timezone,
+		WorkStart:         s.WorkStart,
+		WorkEnd:           s.WorkEnd,
+		RequiredReviewers: s.RequiredReviewers,
+		ExcludedReviewers: s.ExcludedReviewers,
+	}
+}
+
+func (dto UserSettingsDTO) toModel() model.UserSettings {
+	return model.UserSettings{
+		MaxOpenReviews:    dto.MaxOpenReviews,
+		Level:             model.Level(dto.Level),
+		Timezone:          dto.Timezone,
+		WorkStart:         dto.WorkStart,
+		WorkEnd:           dto.WorkEnd,
+		RequiredReviewers: dto.RequiredReviewers,
+		ExcludedReviewers: dto.ExcludedReviewers,
+	}
 }
 
 func (h *Handler) SetUserIsActive(w http.ResponseWriter, r *http.Request) {
@@ -32,7 +78,7 @@ func (h *Handler) SetUserIsActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.svc.SetUserIsActive(req.UserID, req.IsActive)
+	user, err := h.svc.SetUserIsActive(r.Context(), req.UserID, req.IsActive)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
@@ -50,12 +96,61 @@ func (h *Handler) SetUserIsActive(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := map[string]any{
-		"user": UserDTO{
-			UserID:   user.ID,
-			Username: user.Username,
-			TeamName: user.TeamName,
-			IsActive: user.IsActive,
-		},
+		"user": newUserDTO(user),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type SetUserSettingsRequest struct {
+	UserID   string          `json:"user_id"`
+	Settings UserSettingsDTO `json:"settings"`
+}
+
+// POST /users/settings — заменяет настройки пользователя целиком.
+func (h *Handler) SetUserSettings(w http.ResponseWriter, r *http.Request) {
+	var req SetUserSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.svc.SetUserSettings(r.Context(), req.UserID, req.Settings.toModel())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "NOT_FOUND",
+					"message": "resource not found",
+				},
+			})
+			return
+		case errors.Is(err, service.ErrInvalidSettings):
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"code":    "INVALID_SETTINGS",
+					"message": err.Error(),
+				},
+			})
+			return
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resp := map[string]any{
+		"user": newUserDTO(user),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -82,7 +177,7 @@ func (h *Handler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prs, err := h.svc.GetUserReviews(userID)
+	prs, err := h.svc.GetUserReviews(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
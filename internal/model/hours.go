@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// WorkingHours — рабочее время пользователя: минуты от полуночи
+// в его часовом поясе.
+type WorkingHours struct {
+	loc        *time.Location
+	start, end int
+}
+
+// WorkingHours разбирает рабочие часы из настроек; nil — часы не заданы.
+func (s UserSettings) WorkingHours() (*WorkingHours, error) {
+	loc := time.UTC
+	if s.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(s.Timezone); err != nil {
+			return nil, fmt.Errorf("unknown timezone %q", s.Timezone)
+		}
+	}
+
+	if s.WorkStart == "" && s.WorkEnd == "" {
+		return nil, nil
+	}
+	if s.WorkStart == "" || s.WorkEnd == "" {
+		return nil, fmt.Errorf("work_start and work_end must be set together")
+	}
+	start, err := parseClock(s.WorkStart)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(s.WorkEnd)
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, fmt.Errorf("work_start and work_end must differ")
+	}
+	return &WorkingHours{loc: loc, start: start, end: end}, nil
+}
+
+// parseClock переводит "HH:MM" в минуты от полуночи.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// shift возвращает рабочую смену, которая начинается в день t со сдвигом
+// на days дней.
+func (h *WorkingHours) shift(t time.Time, days int) (from, to time.Time) {
+	// границы строятся по часам на стене, а не сдвигом от полуночи:
+	// в день перевода часов в сутках 23 или 25 часов
+	y, m, d := t.In(h.loc).Date()
+	d += days
+	from = time.Date(y, m, d, h.start/60, h.start%60, 0, 0, h.loc)
+	if h.end < h.start {
+		d++
+	}
+	to = time.Date(y, m, d, h.end/60, h.end%60, 0, 0, h.loc)
+	return from, to
+}
+
+// Contains сообщает, идёт ли в момент t рабочее время.
+func (h *WorkingHours) Contains(t time.Time) bool {
+	// смена через полночь могла начаться вчера
+	for _, d := range []int{-1, 0} {
+		from, to := h.shift(t, d)
+		if !t.Before(from) && t.Before(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps сообщает, пересекаются ли смены h и o в сутки вокруг t.
+func (h *WorkingHours) Overlaps(o *WorkingHours, t time.Time) bool {
+	for _, dh := range []int{-1, 0, 1} {
+		hFrom, hTo := h.shift(t, dh)
+		for _, do := range []int{-1, 0, 1} {
+			oFrom, oTo := o.shift(t, do)
+			if hFrom.Before(oTo) && oFrom.Before(hTo) {
+				return true
+			}
+		}
+	}
+	return false
+}
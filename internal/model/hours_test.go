@@ -0,0 +1,112 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+func hours(t *testing.T, tz, start, end string) *model.WorkingHours {
+	t.Helper()
+	h, err := model.UserSettings{Timezone: tz, WorkStart: start, WorkEnd: end}.WorkingHours()
+	if err != nil {
+		t.Fatalf("WorkingHours(%s %s-%s): %v", tz, start, end, err)
+	}
+	return h
+}
+
+func at(t *testing.T, tz, value string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestWorkingHoursContains(t *testing.T) {
+	tests := []struct {
+		name       string
+		tz         string
+		start, end string
+		now        string // в поясе tz
+		want       bool
+	}{
+		{"inside", "Europe/Moscow", "09:00", "18:00", "2026-03-02 12:00", true},
+		{"start is inside", "Europe/Moscow", "09:00", "18:00", "2026-03-02 09:00", true},
+		{"end is outside", "Europe/Moscow", "09:00", "18:00", "2026-03-02 18:00", false},
+		{"before", "Europe/Moscow", "09:00", "18:00", "2026-03-02 08:59", false},
+		{"night shift evening", "UTC", "22:00", "06:00", "2026-03-02 23:00", true},
+		{"night shift after midnight", "UTC", "22:00", "06:00", "2026-03-03 05:59", true},
+		{"night shift daytime", "UTC", "22:00", "06:00", "2026-03-02 12:00", false},
+
+		// 29 марта 2026 в Берлине часы переводят с 02:00 на 03:00,
+		// 25 октября 2026 — с 03:00 на 02:00
+		{"spring forward, after start", "Europe/Berlin", "09:00", "17:00", "2026-03-29 09:30", true},
+		{"spring forward, before start", "Europe/Berlin", "09:00", "17:00", "2026-03-29 08:30", false},
+		{"spring forward, before end", "Europe/Berlin", "09:00", "17:00", "2026-03-29 16:30", true},
+		{"spring forward, after end", "Europe/Berlin", "09:00", "17:00", "2026-03-29 17:30", false},
+		{"fall back, after start", "Europe/Berlin", "09:00", "17:00", "2026-10-25 09:30", true},
+		{"fall back, before start", "Europe/Berlin", "09:00", "17:00", "2026-10-25 08:30", false},
+		{"fall back, after end", "Europe/Berlin", "09:00", "17:00", "2026-10-25 17:30", false},
+		{"fall back, night shift", "Europe/Berlin", "22:00", "06:00", "2026-10-25 05:30", true},
+		{"fall back, night shift ended", "Europe/Berlin", "22:00", "06:00", "2026-10-25 06:30", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := hours(t, tt.tz, tt.start, tt.end)
+			now := at(t, tt.tz, tt.now)
+			if got := h.Contains(now); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+			// ответ не зависит от пояса, в котором передано время
+			if got := h.Contains(now.UTC()); got != tt.want {
+				t.Errorf("Contains(%s UTC) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkingHoursOverlaps(t *testing.T) {
+	now := at(t, "UTC", "2026-03-02 12:00")
+	moscow := hours(t, "Europe/Moscow", "09:00", "18:00")     // 06:00–15:00 UTC
+	newYork := hours(t, "America/New_York", "09:00", "17:00") // 14:00–22:00 UTC
+	tokyo := hours(t, "Asia/Tokyo", "09:00", "18:00")         // 00:00–09:00 UTC
+
+	if !moscow.Overlaps(newYork, now) {
+		t.Error("Moscow and New York should overlap")
+	}
+	if moscow.Overlaps(tokyo, now) != tokyo.Overlaps(moscow, now) {
+		t.Error("Overlaps should be symmetric")
+	}
+	if newYork.Overlaps(tokyo, now) {
+		t.Error("New York and Tokyo should not overlap")
+	}
+}
+
+func TestWorkingHoursInvalid(t *testing.T) {
+	tests := []model.UserSettings{
+		{Timezone: "Mars/Base"},
+		{WorkStart: "09:00"},
+		{WorkStart: "9am", WorkEnd: "18:00"},
+		{WorkStart: "09:00", WorkEnd: "25:00"},
+		{WorkStart: "09:00", WorkEnd: "09:00"},
+	}
+	for _, s := range tests {
+		if _, err := s.WorkingHours(); err == nil {
+			t.Errorf("WorkingHours(%+v) = nil error", s)
+		}
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil error", s)
+		}
+	}
+
+	if h, err := (model.UserSettings{Timezone: "Europe/Moscow"}).WorkingHours(); h != nil || err != nil {
+		t.Errorf("timezone only: got %v, %v, want no hours", h, err)
+	}
+}
@@ -7,11 +7,84 @@ type User struct {
 	Username string
 	TeamName string
 	IsActive bool
+	Settings UserSettings
 }
 
+// UserSettings — личные настройки назначения ревьювера.
+// Настройки и записи журнала назначений хранятся как JSON, поэтому поля
+// размечены тегами.
+type UserSettings struct {
+	// MaxOpenReviews — сколько открытых ревью может быть у пользователя
+	// одновременно; 0 — как в настройках команды.
+	MaxOpenReviews int `json:"max_open_reviews,omitempty"`
+
+	// Level — уровень пользователя; пусто — не задан.
+	Level Level `json:"level,omitempty"`
+
+	// Timezone — часовой пояс IANA (Europe/Berlin); пусто — UTC.
+	// WorkStart и WorkEnd — начало и конец рабочего дня в нём, "HH:MM";
+	// конец раньше начала — смена через полночь. Пусто — часы не заданы.
+	Timezone  string `json:"timezone,omitempty"`
+	WorkStart string `json:"work_start,omitempty"`
+	WorkEnd   string `json:"work_end,omitempty"`
+
+	// RequiredReviewers — кого всегда назначать на PR пользователя,
+	// ExcludedReviewers — кого не назначать никогда.
+	RequiredReviewers []string `json:"required_reviewers,omitempty"`
+	ExcludedReviewers []string `json:"excluded_reviewers,omitempty"`
+}
+
+type Level string
+
+const (
+	LevelJunior Level = "junior"
+	LevelMiddle Level = "middle"
+	LevelSenior Level = "senior"
+)
+
 type Team struct {
-	Name    string
-	Members []User
+	Name     string
+	Members  []User
+	Settings TeamSettings
+	Version  int64 // растёт при каждом изменении команды или её участников
+}
+
+// TeamSettings — настройки назначения ревьюверов в команде.
+type TeamSettings struct {
+	// ReviewerStrategy — имя стратегии выбора ревьюверов; пусто — глобальная по умолчанию.
+	ReviewerStrategy string `json:"reviewer_strategy,omitempty"`
+
+	// ReviewerCount — сколько ревьюверов назначать на PR; 0 — по умолчанию.
+	// MinReviewers и MaxReviewers ограничивают число, запрошенное при создании
+	// PR; 0 — без ограничения (но не меньше одного).
+	ReviewerCount int `json:"reviewer_count,omitempty"`
+	MinReviewers  int `json:"min_reviewers,omitempty"`
+	MaxReviewers  int `json:"max_reviewers,omitempty"`
+
+	// CodeOwners — правила владения путями в синтаксисе CODEOWNERS.
+	// OwnerGroups — группы участников, на которые правила ссылаются как @team/group.
+	CodeOwners  string              `json:"codeowners,omitempty"`
+	OwnerGroups map[string][]string `json:"owner_groups,omitempty"`
+
+	// FallbackTeams — команды в порядке приоритета, из которых добираются
+	// ревьюверы, если в своей команде не хватает активных кандидатов.
+	FallbackTeams []string `json:"fallback_teams,omitempty"`
+
+	// MaxOpenReviews — сколько открытых ревью может быть у участника,
+	// если он не задал своё число; 0 — без ограничения.
+	MaxOpenReviews int `json:"max_open_reviews,omitempty"`
+
+	// PairingWindowDays — за сколько последних дней стратегия pairing-aware
+	// учитывает, кто ревьюил автора; 0 — по умолчанию.
+	PairingWindowDays int `json:"pairing_window_days,omitempty"`
+
+	// RequireSenior — на каждом PR команды должен быть хотя бы один
+	// ревьювер уровня senior.
+	RequireSenior bool `json:"require_senior,omitempty"`
+
+	// Mentees — новички команды: их не назначают ревьюверами, а добавляют
+	// на PR команды теневыми ревьюверами, чтобы они учились на чужих ревью.
+	Mentees []string `json:"mentees,omitempty"`
 }
 
 type PRStatus string
@@ -27,6 +100,73 @@ type PullRequest struct {
 	AuthorID          string
 	Status            PRStatus
 	AssignedReviewers []string
+	ChangedPaths      []string // пути файлов, которые затрагивает PR; по ним ищутся владельцы
+	ExcludedReviewers []string // кого нельзя назначать на этот PR, в том числе при переназначении
+	ShadowReviewer    string   // подопечный, который смотрит ревью, но не одобряет; пусто — нет
 	CreatedAt         *time.Time
 	MergedAt          *time.Time
+	Version           int64 // растёт при каждом изменении PR
+}
+
+// Pairing — факт назначения ревьювера на PR автора.
+type Pairing struct {
+	AuthorID      string
+	ReviewerID    string
+	PullRequestID string
+	AssignedAt    time.Time
+}
+
+// AssignmentRecord — запись журнала назначений: как выбирались ревьюверы
+// при создании PR или переназначении.
+type AssignmentRecord struct {
+	PullRequestID string `json:"pull_request_id"`
+	// Action — create или reassign.
+	Action string `json:"action"`
+	// ReplacedUserID — кого заменили при переназначении.
+	ReplacedUserID string          `json:"replaced_user_id,omitempty"`
+	Assigned       []string        `json:"assigned"`
+	Steps          []SelectionStep `json:"steps"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+const (
+	ActionCreate   = "create"
+	ActionReassign = "reassign"
+)
+
+// SelectionStep — один выбор из пула кандидатов: владельцы области,
+// senior, участники своей или запасной команды.
+type SelectionStep struct {
+	Purpose  string      `json:"purpose"`
+	Team     string      `json:"team"`
+	Strategy string      `json:"strategy"`
+	Count    int         `json:"count"` // сколько нужно было выбрать
+	Pool     []string    `json:"pool"`  // кого рассматривали
+	Excluded []Exclusion `json:"excluded,omitempty"`
+	// OffHours — кандидаты вне рабочего времени: их выбирали, только если
+	// остальных не хватило.
+	OffHours []string `json:"off_hours,omitempty"`
+	Chosen   []string `json:"chosen"`
+}
+
+// Exclusion — кандидат из пула, которого нельзя было назначить, и причина.
+type Exclusion struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// Absence — период, когда пользователя нельзя назначать ревьювером,
+// например отпуск. IsActive при этом не меняется.
+type Absence struct {
+	ID     string
+	UserID string
+	// Start и End — начало и конец периода; End не входит в период.
+	Start  time.Time
+	End    time.Time
+	Reason string
+}
+
+// Covers сообщает, попадает ли t в период отсутствия.
+func (a Absence) Covers(t time.Time) bool {
+	return !t.Before(a.Start) && t.Before(a.End)
 }
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/codeowners"
+)
+
+// ErrInvalidSettings — настройки команды или пользователя не прошли проверку;
+// подробности в тексте ошибки.
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// Имена стратегий выбора ревьюверов для TeamSettings.ReviewerStrategy.
+// Сами стратегии — в пакете service.
+const (
+	StrategyRandom       = "random"
+	StrategyLeastLoaded  = "least-loaded"
+	StrategyRoundRobin   = "round-robin"
+	StrategyPairingAware = "pairing-aware"
+)
+
+// KnownStrategy сообщает, есть ли стратегия с таким именем.
+func KnownStrategy(name string) bool {
+	switch name {
+	case StrategyRandom, StrategyLeastLoaded, StrategyRoundRobin, StrategyPairingAware:
+		return true
+	}
+	return false
+}
+
+// ReviewerLimits возвращает пределы числа ревьюверов; max 0 — без ограничения.
+func (s TeamSettings) ReviewerLimits() (min, max int) {
+	min = s.MinReviewers
+	if min == 0 {
+		min = 1
+	}
+	return min, s.MaxReviewers
+}
+
+// Validate проверяет настройки команды. Так их проверяет и API, и импорт,
+// поэтому в хранилище не попадают настройки, на которых назначение сломается.
+func (s TeamSettings) Validate() error {
+	if s.ReviewerStrategy != "" && !KnownStrategy(s.ReviewerStrategy) {
+		return fmt.Errorf("%w: unknown reviewer strategy %q", ErrInvalidSettings, s.ReviewerStrategy)
+	}
+
+	if s.ReviewerCount < 0 || s.MinReviewers < 0 || s.MaxReviewers < 0 {
+		return fmt.Errorf("%w: reviewer counts must not be negative", ErrInvalidSettings)
+	}
+	if s.MaxReviewers > 0 && s.MinReviewers > s.MaxReviewers {
+		return fmt.Errorf("%w: min_reviewers %d is greater than max_reviewers %d",
+			ErrInvalidSettings, s.MinReviewers, s.MaxReviewers)
+	}
+	if n := s.ReviewerCount; n > 0 {
+		min, max := s.ReviewerLimits()
+		if n < min || (max > 0 && n > max) {
+			return fmt.Errorf("%w: reviewer_count %d is outside [min_reviewers, max_reviewers]", ErrInvalidSettings, n)
+		}
+	}
+
+	if _, err := codeowners.Parse(s.CodeOwners); err != nil {
+		return fmt.Errorf("%w: codeowners: %v", ErrInvalidSettings, err)
+	}
+	for group, members := range s.OwnerGroups {
+		if group == "" || strings.Contains(group, "/") {
+			return fmt.Errorf("%w: invalid owner group name %q", ErrInvalidSettings, group)
+		}
+		for _, id := range members {
+			if id == "" {
+				return fmt.Errorf("%w: owner group %q has an empty user_id", ErrInvalidSettings, group)
+			}
+		}
+	}
+
+	for i, name := range s.FallbackTeams {
+		if name == "" {
+			return fmt.Errorf("%w: empty fallback team name", ErrInvalidSettings)
+		}
+		if slices.Contains(s.FallbackTeams[:i], name) {
+			return fmt.Errorf("%w: fallback team %q is listed twice", ErrInvalidSettings, name)
+		}
+	}
+
+	if s.MaxOpenReviews < 0 {
+		return fmt.Errorf("%w: max_open_reviews must not be negative", ErrInvalidSettings)
+	}
+	if s.PairingWindowDays < 0 {
+		return fmt.Errorf("%w: pairing_window_days must not be negative", ErrInvalidSettings)
+	}
+	for i, id := range s.Mentees {
+		if id == "" {
+			return fmt.Errorf("%w: empty mentee user_id", ErrInvalidSettings)
+		}
+		if slices.Contains(s.Mentees[:i], id) {
+			return fmt.Errorf("%w: mentee %q is listed twice", ErrInvalidSettings, id)
+		}
+	}
+	return nil
+}
+
+// Validate проверяет личные настройки пользователя.
+func (s UserSettings) Validate() error {
+	if s.MaxOpenReviews < 0 {
+		return fmt.Errorf("%w: max_open_reviews must not be negative", ErrInvalidSettings)
+	}
+	switch s.Level {
+	case "", LevelJunior, LevelMiddle, LevelSenior:
+	default:
+		return fmt.Errorf("%w: unknown level %q", ErrInvalidSettings, s.Level)
+	}
+	if _, err := s.WorkingHours(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if err := CheckReviewerLists(s.RequiredReviewers, s.ExcludedReviewers); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	return nil
+}
+
+// CheckReviewerLists проверяет списки обязательных и исключённых ревьюверов:
+// id не пустые, и никто не попал в оба списка.
+func CheckReviewerLists(required, excluded []string) error {
+	for _, id := range slices.Concat(required, excluded) {
+		if id == "" {
+			return errors.New("reviewer id must not be empty")
+		}
+	}
+	for _, id := range required {
+		if slices.Contains(excluded, id) {
+			return fmt.Errorf("user %s is both required and excluded", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo/repotest"
+)
+
+// go test -run '^$' -bench . ./internal/repo
+
+func BenchmarkMemoryRepo(b *testing.B) {
+	b.Run("index", func(b *testing.B) {
+		repotest.BenchmarkGetPullRequestsByReviewer(b, func(tb testing.TB) repo.Repository {
+			return repo.NewMemoryRepo()
+		})
+	})
+	b.Run("full-scan", func(b *testing.B) {
+		repotest.BenchmarkGetPullRequestsByReviewer(b, func(tb testing.TB) repo.Repository {
+			return repo.FullScanRepo{MemoryRepo: repo.NewMemoryRepo()}
+		})
+	})
+}
+
+func BenchmarkSQLiteRepo(b *testing.B) {
+	repotest.BenchmarkGetPullRequestsByReviewer(b, newSQLiteRepo)
+}
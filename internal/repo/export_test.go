@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+// FullScanRepo — MemoryRepo, который ищет PR ревьювера перебором всех PR,
+// как до индекса ревьювер → PR. Нужен бенчмарку для сравнения.
+type FullScanRepo struct {
+	*MemoryRepo
+}
+
+func (r FullScanRepo) GetPullRequestsByReviewer(ctx context.Context, userID string) ([]model.PullRequest, error) {
+	m := r.MemoryRepo
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []model.PullRequest
+	for id := range m.prs {
+		if !slices.Contains(m.reviewers[id], userID) {
+			continue
+		}
+		pr, err := m.getPullRequestByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *pr)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// FaultyJournal подменяет файл журнала m: следующие writes записей
+// обрываются на середине с ошибкой, а если failTruncate, откатить файл
+// тоже не получается.
+func FaultyJournal(m *MemoryRepo, writes int, failTruncate bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.journal.f = &faultyFile{logFile: m.journal.f, writes: writes, failTruncate: failTruncate}
+}
+
+var errInjected = errors.New("injected write error")
+
+type faultyFile struct {
+	logFile
+	writes       int
+	failTruncate bool
+}
+
+func (f *faultyFile) Write(p []byte) (int, error) {
+	if f.writes == 0 {
+		return f.logFile.Write(p)
+	}
+	f.writes--
+	n, _ := f.logFile.Write(p[:len(p)/2])
+	return n, errInjected
+}
+
+func (f *faultyFile) Truncate(size int64) error {
+	if f.failTruncate {
+		return errInjected
+	}
+	return f.logFile.Truncate(size)
+}
@@ -0,0 +1,537 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+// Персистентность MemoryRepo: каждая мутация дописывается в журнал journal.log,
+// раз в snapshotEvery записей состояние целиком сбрасывается в snapshot.json,
+// а журнал обнуляется. При старте снапшот загружается, и поверх него
+// проигрываются записи журнала с seq больше, чем у снапшота.
+//
+// Формат записи журнала — одна строка: "<crc32 hex> <json>\n".
+// Недописанная или битая последняя строка (процесс упал посреди записи)
+// отрезается при открытии. Если запись не удалась у работающего процесса,
+// файл сразу откатывается к концу последней целой записи.
+
+const (
+	journalFile  = "journal.log"
+	snapshotFile = "snapshot.json"
+
+	DefaultSnapshotEvery = 1000
+)
+
+const (
+	opCreateTeam          = "create_team"
+	opSetUserActive       = "set_user_active"
+	opSetUserSettings     = "set_user_settings"
+	opSetTeamSettings     = "set_team_settings"
+	opSetRotationCursor   = "set_rotation_cursor"
+	opCreatePullRequest   = "create_pull_request"
+	opUpdatePullRequest   = "update_pull_request"
+	opSetReviewers        = "set_reviewers"
+	opAddPairings         = "add_pairings"
+	opAddAssignmentRecord = "add_assignment_record"
+	opAddAbsence          = "add_absence"
+	opDeleteAbsence       = "delete_absence"
+	opPruneAbsences       = "prune_absences"
+	opBatch               = "batch" // все изменения одной транзакции
+)
+
+var (
+	ErrCorruptJournal = errors.New("journal is corrupt")
+	// ErrJournalFailed — запись в журнал не удалась, и откатить файл
+	// тоже не вышло; MemoryRepo больше не принимает изменений.
+	ErrJournalFailed = errors.New("journal write failed")
+)
+
+type journalRecord struct {
+	Seq  uint64          `json:"seq"`
+	Op   string          `json:"op"`
+	Data json.RawMessage `json:"data"`
+}
+
+type batchEntry struct {
+	Op   string          `json:"op"`
+	Data json.RawMessage `json:"data"`
+}
+
+type setUserActiveRecord struct {
+	UserID   string `json:"user_id"`
+	IsActive bool   `json:"is_active"`
+}
+
+type setUserSettingsRecord struct {
+	UserID   string             `json:"user_id"`
+	Settings model.UserSettings `json:"settings"`
+}
+
+type setTeamSettingsRecord struct {
+	TeamName string             `json:"team_name"`
+	Settings model.TeamSettings `json:"settings"`
+}
+
+type rotationCursorRecord struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+}
+
+type setReviewersRecord struct {
+	PRID      string   `json:"pull_request_id"`
+	Reviewers []string `json:"reviewers"`
+}
+
+type memorySnapshot struct {
+	Seq          uint64              `json:"seq"`
+	Teams        []model.Team        `json:"teams"`
+	Users        []model.User        `json:"users"`
+	PullRequests []model.PullRequest `json:"pull_requests"`
+	Cursors      map[string]string   `json:"cursors,omitempty"`
+	Pairings     []model.Pairing     `json:"pairings,omitempty"`
+
+	AssignmentLog []model.AssignmentRecord `json:"assignment_log,omitempty"`
+	Absences      []model.Absence          `json:"absences,omitempty"`
+}
+
+// logFile — файл журнала; в тестах его подменяют, чтобы проверить
+// обработку ошибок записи.
+type logFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Truncate(size int64) error
+	Sync() error
+}
+
+type journal struct {
+	dir           string
+	f             logFile
+	size          int64 // конец последней целой записи
+	seq           uint64
+	sinceSnapshot int
+	snapshotEvery int
+	// failed — почему журнал больше не принимает записей; nil — принимает.
+	failed error
+}
+
+// OpenMemoryRepo поднимает MemoryRepo из каталога dir (снапшот + журнал)
+// и дальше пишет туда все изменения. snapshotEvery <= 0 означает DefaultSnapshotEvery.
+func OpenMemoryRepo(dir string, snapshotEvery int) (*MemoryRepo, error) {
+	if snapshotEvery <= 0 {
+		snapshotEvery = DefaultSnapshotEvery
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	m := NewMemoryRepo()
+	j := &journal{dir: dir, snapshotEvery: snapshotEvery}
+
+	snap, err := readSnapshot(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	if snap != nil {
+		m.restore(snap)
+		j.seq = snap.Seq
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	j.f = f
+
+	if err := m.replay(j); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	m.journal = j
+	return m, nil
+}
+
+// Close закрывает файл журнала. Для MemoryRepo без персистентности ничего не делает.
+func (m *MemoryRepo) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.journal == nil {
+		return nil
+	}
+	err := m.journal.f.Close()
+	m.journal = nil
+	return err
+}
+
+// mutate записывает операцию в журнал (если он есть) и только после успешной
+// записи применяет её к состоянию в памяти. Внутри транзакции запись
+// откладывается до коммита. Вызывается под m.mu.Lock.
+func (m *MemoryRepo) mutate(op string, data any, apply func()) error {
+	if m.tx != nil {
+		if err := m.tx.record(op, data); err != nil {
+			return err
+		}
+		apply()
+		return nil
+	}
+
+	if m.journal == nil {
+		apply()
+		return nil
+	}
+
+	if err := m.journal.append(op, data); err != nil {
+		return err
+	}
+	apply()
+
+	if m.journal.sinceSnapshot >= m.journal.snapshotEvery {
+		if err := m.compact(); err != nil {
+			// запись уже в журнале, так что данные не потеряны — попробуем в следующий раз
+			log.Printf("memory repo: snapshot failed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (j *journal) append(op string, data any) error {
+	if j.failed != nil {
+		return j.failed
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(journalRecord{Seq: j.seq + 1, Op: op, Data: payload})
+	if err != nil {
+		return err
+	}
+
+	line := make([]byte, 0, len(body)+10)
+	line = fmt.Appendf(line, "%08x ", crc32.ChecksumIEEE(body))
+	line = append(line, body...)
+	line = append(line, '\n')
+
+	if err := j.write(line); err != nil {
+		// в файле мог остаться обрывок строки: следующая запись легла бы
+		// после него, и при открытии журнал считался бы испорченным
+		if rerr := j.rewind(); rerr != nil {
+			j.failed = fmt.Errorf("%w: %v; rewind: %v", ErrJournalFailed, err, rerr)
+		}
+		return err
+	}
+
+	j.size += int64(len(line))
+	j.seq++
+	j.sinceSnapshot++
+	return nil
+}
+
+func (j *journal) write(line []byte) error {
+	if _, err := j.f.Write(line); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+// rewind отрезает всё после последней целой записи.
+func (j *journal) rewind() error {
+	if err := j.f.Truncate(j.size); err != nil {
+		return err
+	}
+	_, err := j.f.Seek(j.size, io.SeekStart)
+	return err
+}
+
+// replay проигрывает журнал поверх уже загруженного снапшота.
+func (m *MemoryRepo) replay(j *journal) error {
+	if _, err := j.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(j.f)
+	var good int64 // конец последней целой записи
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				// хвост без перевода строки — запись не успела дописаться
+				log.Printf("memory repo: dropping torn journal record at offset %d", good)
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rec, ok := decodeJournalLine(line)
+		if !ok {
+			if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+				log.Printf("memory repo: dropping torn journal record at offset %d", good)
+				break
+			}
+			return fmt.Errorf("%w: bad record at offset %d", ErrCorruptJournal, good)
+		}
+
+		if rec.Seq > j.seq {
+			if err := m.applyRecord(rec); err != nil {
+				return fmt.Errorf("%w: record %d: %v", ErrCorruptJournal, rec.Seq, err)
+			}
+			j.seq = rec.Seq
+			j.sinceSnapshot++
+		}
+		good += int64(len(line))
+	}
+
+	j.size = good
+	return j.rewind()
+}
+
+func decodeJournalLine(line []byte) (journalRecord, bool) {
+	var rec journalRecord
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	sum, body, found := bytes.Cut(line, []byte(" "))
+	if !found {
+		return rec, false
+	}
+	want, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(want) != crc32.ChecksumIEEE(body) {
+		return rec, false
+	}
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return rec, false
+	}
+	return rec, true
+}
+
+func (m *MemoryRepo) applyRecord(rec journalRecord) error {
+	switch rec.Op {
+	case opCreateTeam:
+		var team model.Team
+		if err := json.Unmarshal(rec.Data, &team); err != nil {
+			return err
+		}
+		m.applyCreateTeam(&team)
+	case opSetUserActive:
+		var r setUserActiveRecord
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		m.applySetUserActive(r.UserID, r.IsActive)
+	case opSetUserSettings:
+		var r setUserSettingsRecord
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		m.applySetUserSettings(r.UserID, r.Settings)
+	case opSetTeamSettings:
+		var r setTeamSettingsRecord
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		m.applySetTeamSettings(r.TeamName, r.Settings)
+	case opSetRotationCursor:
+		var r rotationCursorRecord
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		m.applySetRotationCursor(r.TeamName, r.UserID)
+	case opCreatePullRequest, opUpdatePullRequest:
+		var pr model.PullRequest
+		if err := json.Unmarshal(rec.Data, &pr); err != nil {
+			return err
+		}
+		m.applyPutPullRequest(&pr)
+	case opSetReviewers:
+		var r setReviewersRecord
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		m.applySetReviewers(r.PRID, r.Reviewers)
+	case opAddPairings:
+		var pairings []model.Pairing
+		if err := json.Unmarshal(rec.Data, &pairings); err != nil {
+			return err
+		}
+		m.applyAddPairings(pairings)
+	case opAddAssignmentRecord:
+		var r model.AssignmentRecord
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		m.applyAddAssignmentRecord(r)
+	case opAddAbsence:
+		var a model.Absence
+		if err := json.Unmarshal(rec.Data, &a); err != nil {
+			return err
+		}
+		m.applyAddAbsence(a)
+	case opDeleteAbsence:
+		var id string
+		if err := json.Unmarshal(rec.Data, &id); err != nil {
+			return err
+		}
+		m.applyDeleteAbsence(id)
+	case opPruneAbsences:
+		var ids []string
+		if err := json.Unmarshal(rec.Data, &ids); err != nil {
+			return err
+		}
+		m.applyPruneAbsences(ids)
+	case opBatch:
+		var entries []batchEntry
+		if err := json.Unmarshal(rec.Data, &entries); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := m.applyRecord(journalRecord{Seq: rec.Seq, Op: e.Op, Data: e.Data}); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+	return nil
+}
+
+// compact сохраняет снапшот текущего состояния и обнуляет журнал.
+// Если процесс упадёт между rename и truncate, при старте записи журнала
+// с seq <= snap.Seq будут пропущены.
+func (m *MemoryRepo) compact() error {
+	j := m.journal
+
+	data, err := json.Marshal(m.snapshot(j.seq))
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(j.dir, snapshotFile)
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+
+	if err := j.f.Truncate(0); err != nil {
+		return err
+	}
+	j.size = 0
+	if _, err := j.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	j.sinceSnapshot = 0
+	return j.f.Sync()
+}
+
+func (m *MemoryRepo) snapshot(seq uint64) *memorySnapshot {
+	snap := &memorySnapshot{Seq: seq, Cursors: make(map[string]string, len(m.cursors))}
+
+	for _, t := range m.teams {
+		snap.Teams = append(snap.Teams, *t)
+	}
+	for _, u := range m.users {
+		snap.Users = append(snap.Users, *u)
+	}
+	for id, pr := range m.prs {
+		copyPR := *pr
+		copyPR.AssignedReviewers = m.reviewers[id]
+		snap.PullRequests = append(snap.PullRequests, copyPR)
+	}
+	for team, c := range m.cursors {
+		snap.Cursors[team] = c
+	}
+	snap.Pairings = m.allPairings()
+	snap.AssignmentLog = m.allAssignmentRecords()
+	snap.Absences = m.allAbsences()
+
+	// стабильный порядок, чтобы снапшоты одного состояния совпадали побайтно
+	sort.Slice(snap.Teams, func(i, k int) bool { return snap.Teams[i].Name < snap.Teams[k].Name })
+	sort.Slice(snap.Users, func(i, k int) bool { return snap.Users[i].ID < snap.Users[k].ID })
+	sort.Slice(snap.PullRequests, func(i, k int) bool { return snap.PullRequests[i].ID < snap.PullRequests[k].ID })
+
+	return snap
+}
+
+func (m *MemoryRepo) restore(snap *memorySnapshot) {
+	for i := range snap.Teams {
+		t := snap.Teams[i]
+		m.teams[t.Name] = &t
+	}
+	for i := range snap.Users {
+		u := snap.Users[i]
+		m.users[u.ID] = &u
+	}
+	for i := range snap.PullRequests {
+		m.applyPutPullRequest(&snap.PullRequests[i])
+	}
+	for team, c := range snap.Cursors {
+		m.cursors[team] = c
+	}
+	m.applyAddPairings(snap.Pairings)
+	for _, rec := range snap.AssignmentLog {
+		m.applyAddAssignmentRecord(rec)
+	}
+	for _, a := range snap.Absences {
+		m.applyAddAbsence(a)
+	}
+}
+
+func readSnapshot(path string) (*memorySnapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snap memorySnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
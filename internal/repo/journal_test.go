@@ -0,0 +1,164 @@
+package repo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo/repotest"
+)
+
+// TestJournalRepo прогоняет набор на MemoryRepo с журналом, а после каждого
+// теста переоткрывает каталог и сверяет восстановленное состояние.
+// Снапшот пишется через каждые две записи, так что проигрываются и снапшот,
+// и хвост журнала.
+func TestJournalRepo(t *testing.T) {
+	repotest.Run(t, func(tb testing.TB) repo.Repository {
+		dir := tb.TempDir()
+		m, err := repo.OpenMemoryRepo(dir, 2)
+		if err != nil {
+			tb.Fatalf("OpenMemoryRepo: %v", err)
+		}
+
+		tb.Cleanup(func() {
+			before := dumpState(tb, m)
+			if err := m.Close(); err != nil {
+				tb.Fatalf("Close: %v", err)
+			}
+
+			reopened, err := repo.OpenMemoryRepo(dir, 2)
+			if err != nil {
+				tb.Fatalf("reopen: %v", err)
+			}
+			defer reopened.Close()
+
+			if after := dumpState(tb, reopened); after != before {
+				tb.Errorf("state after reopen differs\nbefore:\n%s\nafter:\n%s", before, after)
+			}
+		})
+		return m
+	})
+}
+
+// dumpState описывает всё состояние хранилища текстом для сравнения.
+func dumpState(tb testing.TB, r repo.Repository) string {
+	tb.Helper()
+	ctx := context.Background()
+	var b strings.Builder
+
+	teams, err := r.ListTeams(ctx)
+	if err != nil {
+		tb.Fatalf("ListTeams: %v", err)
+	}
+	for _, team := range teams {
+		cursor, err := r.GetRotationCursor(ctx, team.Name)
+		if err != nil {
+			tb.Fatalf("GetRotationCursor: %v", err)
+		}
+		fmt.Fprintf(&b, "team %+v cursor=%q\n", team, cursor)
+
+		for _, u := range team.Members {
+			absences, err := r.ListAbsences(ctx, u.ID)
+			if err != nil {
+				tb.Fatalf("ListAbsences: %v", err)
+			}
+			for _, a := range absences {
+				fmt.Fprintf(&b, "absence %s %s %s %s %q\n", a.ID, a.UserID, a.Start, a.End, a.Reason)
+			}
+		}
+	}
+
+	prs, err := r.ListPullRequests(ctx)
+	if err != nil {
+		tb.Fatalf("ListPullRequests: %v", err)
+	}
+	for _, pr := range prs {
+		fmt.Fprintf(&b, "pr %s %q %s %s %v %v %v %q %v %v %d\n",
+			pr.ID, pr.Name, pr.AuthorID, pr.Status, pr.AssignedReviewers, pr.ChangedPaths,
+			pr.ExcludedReviewers, pr.ShadowReviewer, pr.CreatedAt, pr.MergedAt, pr.Version)
+
+		records, err := r.ListAssignmentRecords(ctx, pr.ID)
+		if err != nil {
+			tb.Fatalf("ListAssignmentRecords: %v", err)
+		}
+		for _, rec := range records {
+			fmt.Fprintf(&b, "log %+v\n", rec)
+		}
+	}
+
+	pairings, err := r.ListPairings(ctx)
+	if err != nil {
+		tb.Fatalf("ListPairings: %v", err)
+	}
+	for _, p := range pairings {
+		fmt.Fprintf(&b, "pairing %+v\n", p)
+	}
+	return b.String()
+}
+
+func TestJournalWriteError(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name         string
+		failTruncate bool
+	}{
+		{"Rewound", false},
+		{"RewindFails", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			m, err := repo.OpenMemoryRepo(dir, 100)
+			if err != nil {
+				t.Fatalf("OpenMemoryRepo: %v", err)
+			}
+			team := &model.Team{Name: "backend", Members: []model.User{
+				{ID: "u1", Username: "alice", IsActive: true},
+				{ID: "u2", Username: "bob", IsActive: true},
+			}}
+			if err := m.CreateTeam(ctx, team); err != nil {
+				t.Fatalf("CreateTeam: %v", err)
+			}
+
+			repo.FaultyJournal(m, 1, tc.failTruncate)
+			if _, err := m.SetUserActive(ctx, "u1", false); err == nil {
+				t.Fatal("SetUserActive with a failing write succeeded")
+			}
+			u, err := m.GetUserByID(ctx, "u1")
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+			if !u.IsActive {
+				t.Error("failed write was applied in memory")
+			}
+
+			_, err = m.SetUserActive(ctx, "u2", false)
+			if tc.failTruncate {
+				// обрывок записи остался в файле — дальше писать нельзя
+				if !errors.Is(err, repo.ErrJournalFailed) {
+					t.Errorf("SetUserActive after failed rewind: err = %v, want ErrJournalFailed", err)
+				}
+			} else if err != nil {
+				t.Fatalf("SetUserActive after failed write: %v", err)
+			}
+			before := dumpState(t, m)
+			if err := m.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			// журнал открывается, в нём всё, что было подтверждено
+			reopened, err := repo.OpenMemoryRepo(dir, 100)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			defer reopened.Close()
+			if after := dumpState(t, reopened); after != before {
+				t.Errorf("state after reopen differs\nbefore:\n%s\nafter:\n%s", before, after)
+			}
+		})
+	}
+}
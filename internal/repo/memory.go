@@ -1,7 +1,9 @@
 package repo
 
 import (
+	"context"
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/iamyblitz/pr-reviewer-service/internal/model"
@@ -11,6 +13,12 @@ var (
 	ErrNotFound   = errors.New("not found")
 	ErrTeamExists = errors.New("team already exists")
 	ErrPRExists   = errors.New("pr already exists")
+	ErrTxDone     = errors.New("transaction already finished")
+
+	ErrAbsenceExists = errors.New("absence already exists")
+
+	// ErrVersionConflict — PR изменился с момента чтения (pr.Version устарел).
+	ErrVersionConflict = errors.New("version conflict")
 )
 
 type MemoryRepo struct {
@@ -20,6 +28,19 @@ type MemoryRepo struct {
 	users     map[string]*model.User        // по user_id
 	prs       map[string]*model.PullRequest // по pull_request_id
 	reviewers map[string][]string           // по pull_request_id
+
+	byReviewer map[string]idSet         // user_id → pull_request_id
+	byStatus   map[model.PRStatus]idSet // статус → pull_request_id
+
+	cursors  map[string]string          // team_name → id последнего ревьювера по кругу
+	pairings map[string][]model.Pairing // по author_id, в порядке добавления
+
+	assignmentLog map[string][]model.AssignmentRecord // по pull_request_id, в порядке добавления
+	absences      map[string]*model.Absence           // по id
+	userAbsences  map[string]idSet                    // user_id → id отсутствия
+
+	journal *journal  // nil, если персистентность не включена
+	tx      *memoryTx // текущая транзакция, под m.mu.Lock
 }
 
 func NewMemoryRepo() *MemoryRepo {
@@ -28,31 +49,169 @@ func NewMemoryRepo() *MemoryRepo {
 		users:     make(map[string]*model.User),
 		prs:       make(map[string]*model.PullRequest),
 		reviewers: make(map[string][]string),
+
+		byReviewer: make(map[string]idSet),
+		byStatus:   make(map[model.PRStatus]idSet),
+
+		cursors:  make(map[string]string),
+		pairings: make(map[string][]model.Pairing),
+
+		assignmentLog: make(map[string][]model.AssignmentRecord),
+		absences:      make(map[string]*model.Absence),
+		userAbsences:  make(map[string]idSet),
 	}
 }
 
-func (m *MemoryRepo) CreateTeam(team *model.Team) error {
+// Публичные методы берут блокировку и делегируют в методы без блокировки,
+// которыми же пользуется memoryTx внутри WithTx. Отмена контекста
+// проверяется уже под блокировкой: ожидание на ней может быть долгим.
+
+func (m *MemoryRepo) CreateTeam(ctx context.Context, team *model.Team) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createTeam(ctx, team)
+}
+
+func (m *MemoryRepo) GetTeam(ctx context.Context, name string) (*model.Team, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getTeam(ctx, name)
+}
+
+func (m *MemoryRepo) ListTeams(ctx context.Context) ([]model.Team, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.listTeams(ctx)
+}
+
+func (m *MemoryRepo) SetTeamSettings(ctx context.Context, name string, settings model.TeamSettings) (*model.Team, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setTeamSettings(ctx, name, settings)
+}
+
+func (m *MemoryRepo) GetRotationCursor(ctx context.Context, teamName string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getRotationCursor(ctx, teamName)
+}
+
+func (m *MemoryRepo) SetRotationCursor(ctx context.Context, teamName, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setRotationCursor(ctx, teamName, userID)
+}
+
+func (m *MemoryRepo) SetUserActive(ctx context.Context, userID string, isActive bool) (*model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setUserActive(ctx, userID, isActive)
+}
+
+func (m *MemoryRepo) SetUserSettings(ctx context.Context, userID string, settings model.UserSettings) (*model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setUserSettings(ctx, userID, settings)
+}
+
+func (m *MemoryRepo) GetUserByID(ctx context.Context, userID string) (*model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getUserByID(ctx, userID)
+}
+
+func (m *MemoryRepo) CreatePullRequest(ctx context.Context, pr *model.PullRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createPullRequest(ctx, pr)
+}
+
+func (m *MemoryRepo) GetPullRequestByID(ctx context.Context, id string) (*model.PullRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getPullRequestByID(ctx, id)
+}
+
+func (m *MemoryRepo) UpdatePullRequest(ctx context.Context, pr *model.PullRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updatePullRequest(ctx, pr)
+}
+
+func (m *MemoryRepo) SetReviewers(ctx context.Context, prID string, reviewers []string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.setReviewers(ctx, prID, reviewers)
+}
+
+func (m *MemoryRepo) GetReviewers(ctx context.Context, prID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getReviewers(ctx, prID)
+}
+
+func (m *MemoryRepo) GetPullRequestsByReviewer(ctx context.Context, userID string) ([]model.PullRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getPullRequestsByReviewer(ctx, userID)
+}
+
+func (m *MemoryRepo) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.countOpenReviews(ctx, userIDs)
+}
+
+func (m *MemoryRepo) GetPullRequestsByStatus(ctx context.Context, status model.PRStatus) ([]model.PullRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getPullRequestsByStatus(ctx, status)
+}
+
+func (m *MemoryRepo) ListPullRequests(ctx context.Context) ([]model.PullRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.listPullRequests(ctx)
+}
+
+func (m *MemoryRepo) createTeam(ctx context.Context, team *model.Team) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	if _, exists := m.teams[team.Name]; exists {
 		return ErrTeamExists
 	}
-	m.teams[team.Name] = team
 
+	return m.mutate(opCreateTeam, team, func() {
+		m.applyCreateTeam(team)
+	})
+}
+
+func (m *MemoryRepo) applyCreateTeam(team *model.Team) {
+	m.tx.saveTeam(m, team.Name)
+
+	stored := &model.Team{
+		Name:     team.Name,
+		Members:  make([]model.User, 0, len(team.Members)),
+		Settings: team.Settings,
+		Version:  1,
+	}
 	for _, u := range team.Members {
+		m.tx.saveUser(m, u.ID)
+
 		user := u // копия
 		user.TeamName = team.Name
 		m.users[user.ID] = &user
+		stored.Members = append(stored.Members, user)
 	}
-
-	return nil
-
+	m.teams[team.Name] = stored
 }
 
-func (m *MemoryRepo) GetTeam(name string) (*model.Team, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+func (m *MemoryRepo) getTeam(ctx context.Context, name string) (*model.Team, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	team, ok := m.teams[name]
 	if !ok {
@@ -60,30 +219,185 @@ func (m *MemoryRepo) GetTeam(name string) (*model.Team, error) {
 	}
 
 	copyTeam := *team
-	copyMembers := make([]model.User, len(team.Members))
-	copy(copyMembers, team.Members)
-	copyTeam.Members = copyMembers
+	copyTeam.Members = make([]model.User, 0, len(team.Members))
+	for _, member := range team.Members {
+		// актуальное состояние берём из m.users: IsActive меняется через SetUserActive,
+		// а пользователь мог перейти в другую команду
+		u, ok := m.users[member.ID]
+		if !ok || u.TeamName != team.Name {
+			continue
+		}
+		copyTeam.Members = append(copyTeam.Members, *u)
+	}
 
 	return &copyTeam, nil
 }
-func (m *MemoryRepo) SetUserActive(userID string, isActive bool) (*model.User, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+
+func (m *MemoryRepo) listTeams(ctx context.Context) ([]model.Team, error) {
+	names := make([]string, 0, len(m.teams))
+	for name := range m.teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	teams := make([]model.Team, 0, len(names))
+	for _, name := range names {
+		team, err := m.getTeam(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, *team)
+	}
+	return teams, nil
+}
+
+func (m *MemoryRepo) setTeamSettings(ctx context.Context, name string, settings model.TeamSettings) (*model.Team, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := m.teams[name]; !ok {
+		return nil, ErrNotFound
+	}
+
+	err := m.mutate(opSetTeamSettings, setTeamSettingsRecord{TeamName: name, Settings: settings}, func() {
+		m.applySetTeamSettings(name, settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.getTeam(ctx, name)
+}
+
+func (m *MemoryRepo) applySetTeamSettings(name string, settings model.TeamSettings) {
+	team, ok := m.teams[name]
+	if !ok {
+		return
+	}
+	m.tx.saveTeam(m, name)
+
+	updated := *team
+	updated.Settings = settings
+	updated.Version++
+	m.teams[name] = &updated
+}
+
+func (m *MemoryRepo) getRotationCursor(ctx context.Context, teamName string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if _, ok := m.teams[teamName]; !ok {
+		return "", ErrNotFound
+	}
+	return m.cursors[teamName], nil
+}
+
+func (m *MemoryRepo) setRotationCursor(ctx context.Context, teamName, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, ok := m.teams[teamName]; !ok {
+		return ErrNotFound
+	}
+
+	return m.mutate(opSetRotationCursor, rotationCursorRecord{TeamName: teamName, UserID: userID}, func() {
+		m.applySetRotationCursor(teamName, userID)
+	})
+}
+
+func (m *MemoryRepo) applySetRotationCursor(teamName, userID string) {
+	m.tx.saveCursor(m, teamName)
+	m.cursors[teamName] = userID
+}
+
+func (m *MemoryRepo) setUserActive(ctx context.Context, userID string, isActive bool) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	u, ok := m.users[userID]
 	if !ok {
 		return nil, ErrNotFound
 	}
 
-	u.IsActive = isActive
+	err := m.mutate(opSetUserActive, setUserActiveRecord{UserID: userID, IsActive: isActive}, func() {
+		m.applySetUserActive(userID, isActive)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	copyUser := *u
+	copyUser := *m.users[u.ID]
 	return &copyUser, nil
 }
 
-func (m *MemoryRepo) GetUserByID(userID string) (*model.User, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+func (m *MemoryRepo) applySetUserActive(userID string, isActive bool) {
+	u, ok := m.users[userID]
+	if !ok {
+		return
+	}
+	m.tx.saveUser(m, userID)
+
+	// заменяем, а не правим по указателю: старое значение может быть нужно для отката
+	updated := *u
+	updated.IsActive = isActive
+	m.users[userID] = &updated
+
+	m.bumpTeamVersion(u.TeamName)
+}
+
+func (m *MemoryRepo) setUserSettings(ctx context.Context, userID string, settings model.UserSettings) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	err := m.mutate(opSetUserSettings, setUserSettingsRecord{UserID: userID, Settings: settings}, func() {
+		m.applySetUserSettings(userID, settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copyUser := *m.users[userID]
+	return &copyUser, nil
+}
+
+func (m *MemoryRepo) applySetUserSettings(userID string, settings model.UserSettings) {
+	u, ok := m.users[userID]
+	if !ok {
+		return
+	}
+	m.tx.saveUser(m, userID)
+
+	updated := *u
+	updated.Settings = settings
+	m.users[userID] = &updated
+
+	m.bumpTeamVersion(u.TeamName)
+}
+
+func (m *MemoryRepo) bumpTeamVersion(name string) {
+	team, ok := m.teams[name]
+	if !ok {
+		return
+	}
+	m.tx.saveTeam(m, name)
+
+	updated := *team
+	updated.Version++
+	m.teams[name] = &updated
+}
+
+func (m *MemoryRepo) getUserByID(ctx context.Context, userID string) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	u, ok := m.users[userID]
 	if !ok {
@@ -94,26 +408,53 @@ func (m *MemoryRepo) GetUserByID(userID string) (*model.User, error) {
 	return &copyUser, nil
 }
 
-func (m *MemoryRepo) CreatePullRequest(pr *model.PullRequest) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *MemoryRepo) createPullRequest(ctx context.Context, pr *model.PullRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	if _, exists := m.prs[pr.ID]; exists {
 		return ErrPRExists
 	}
 
+	created := *pr
+	created.Version = 1
+	err := m.mutate(opCreatePullRequest, &created, func() {
+		m.applyPutPullRequest(&created)
+	})
+	if err != nil {
+		return err
+	}
+
+	pr.Version = created.Version
+	return nil
+}
+
+// applyPutPullRequest сохраняет копию PR вместе со списком ревьюверов
+// и переиндексирует его.
+func (m *MemoryRepo) applyPutPullRequest(pr *model.PullRequest) {
+	m.tx.savePR(m, pr.ID)
+	if old, ok := m.prs[pr.ID]; ok {
+		m.unindexPR(old)
+	}
+
 	copyPR := *pr
-	m.prs[pr.ID] = &copyPR
 
 	reviewersCopy := make([]string, len(pr.AssignedReviewers))
 	copy(reviewersCopy, pr.AssignedReviewers)
-	m.reviewers[pr.ID] = reviewersCopy
+	copyPR.AssignedReviewers = reviewersCopy
+	copyPR.ChangedPaths = append([]string(nil), pr.ChangedPaths...)
+	copyPR.ExcludedReviewers = append([]string(nil), pr.ExcludedReviewers...)
 
-	return nil
+	m.prs[pr.ID] = &copyPR
+	m.reviewers[pr.ID] = reviewersCopy
+	m.indexPR(&copyPR)
 }
-func (m *MemoryRepo) GetPullRequestByID(id string) (*model.PullRequest, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+
+func (m *MemoryRepo) getPullRequestByID(ctx context.Context, id string) (*model.PullRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	pr, ok := m.prs[id]
 	if !ok {
@@ -125,48 +466,68 @@ func (m *MemoryRepo) GetPullRequestByID(id string) (*model.PullRequest, error) {
 	reviewersCopy := make([]string, len(reviewers))
 	copy(reviewersCopy, reviewers)
 	copyPR.AssignedReviewers = reviewersCopy
+	copyPR.ChangedPaths = append([]string(nil), pr.ChangedPaths...)
+	copyPR.ExcludedReviewers = append([]string(nil), pr.ExcludedReviewers...)
 
 	return &copyPR, nil
 }
 
-func (m *MemoryRepo) UpdatePullRequest(pr *model.PullRequest) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *MemoryRepo) updatePullRequest(ctx context.Context, pr *model.PullRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	if _, ok := m.prs[pr.ID]; !ok {
+	current, ok := m.prs[pr.ID]
+	if !ok {
 		return ErrNotFound
 	}
+	if current.Version != pr.Version {
+		return ErrVersionConflict
+	}
 
-	copyPR := *pr
-	m.prs[pr.ID] = &copyPR
-
-	reviewersCopy := make([]string, len(pr.AssignedReviewers))
-	copy(reviewersCopy, pr.AssignedReviewers)
-	m.reviewers[pr.ID] = reviewersCopy
+	updated := *pr
+	updated.Version = current.Version + 1
+	err := m.mutate(opUpdatePullRequest, &updated, func() {
+		m.applyPutPullRequest(&updated)
+	})
+	if err != nil {
+		return err
+	}
 
+	pr.Version = updated.Version
 	return nil
 }
-func (m *MemoryRepo) SetReviewers(prID string, reviewers []string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
 
-	pr, ok := m.prs[prID]
-	if !ok {
+func (m *MemoryRepo) setReviewers(ctx context.Context, prID string, reviewers []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, ok := m.prs[prID]; !ok {
 		return ErrNotFound
 	}
 
-	reviewersCopy := make([]string, len(reviewers))
-	copy(reviewersCopy, reviewers)
-	m.reviewers[prID] = reviewersCopy
+	return m.mutate(opSetReviewers, setReviewersRecord{PRID: prID, Reviewers: reviewers}, func() {
+		m.applySetReviewers(prID, reviewers)
+	})
+}
 
-	pr.AssignedReviewers = reviewersCopy
+func (m *MemoryRepo) applySetReviewers(prID string, reviewers []string) {
+	pr, ok := m.prs[prID]
+	if !ok {
+		return
+	}
 
-	return nil
+	updated := *pr
+	updated.AssignedReviewers = reviewers
+	updated.Version++
+	m.applyPutPullRequest(&updated)
 }
 
-func (m *MemoryRepo) GetReviewers(prID string) ([]string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+func (m *MemoryRepo) getReviewers(ctx context.Context, prID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	reviewers, ok := m.reviewers[prID]
 	if !ok {
@@ -179,25 +540,47 @@ func (m *MemoryRepo) GetReviewers(prID string) ([]string, error) {
 	return reviewersCopy, nil
 }
 
-func (m *MemoryRepo) GetPullRequestsByReviewer(userID string) ([]model.PullRequest, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+func (m *MemoryRepo) getPullRequestsByReviewer(ctx context.Context, userID string) ([]model.PullRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return m.pullRequestsByIDs(ctx, m.byReviewer[userID])
+}
+
+func (m *MemoryRepo) getPullRequestsByStatus(ctx context.Context, status model.PRStatus) ([]model.PullRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return m.pullRequestsByIDs(ctx, m.byStatus[status])
+}
 
-	var result []model.PullRequest
-
-	for prID, pr := range m.prs {
-		reviewers := m.reviewers[prID]
-		for _, r := range reviewers {
-			if r == userID {
-				copyPR := *pr
-				reviewersCopy := make([]string, len(reviewers))
-				copy(reviewersCopy, reviewers)
-				copyPR.AssignedReviewers = reviewersCopy
-				result = append(result, copyPR)
-				break
+func (m *MemoryRepo) listPullRequests(ctx context.Context) ([]model.PullRequest, error) {
+	ids := make(idSet, len(m.prs))
+	for id := range m.prs {
+		ids[id] = struct{}{}
+	}
+	return m.pullRequestsByIDs(ctx, ids)
+}
+
+func (m *MemoryRepo) countOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	open := m.byStatus[model.PRStatusOpen]
+	counts := make(map[string]int, len(userIDs))
+	for _, id := range userIDs {
+		n := 0
+		for prID := range m.byReviewer[id] {
+			if _, ok := open[prID]; ok {
+				n++
 			}
 		}
+		if n > 0 {
+			counts[id] = n
+		}
 	}
-
-	return result, nil
+	return counts, nil
 }
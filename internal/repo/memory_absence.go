@@ -0,0 +1,195 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+func (m *MemoryRepo) AddAbsence(ctx context.Context, absence *model.Absence) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addAbsence(ctx, absence)
+}
+
+func (m *MemoryRepo) DeleteAbsence(ctx context.Context, id string) (*model.Absence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteAbsence(ctx, id)
+}
+
+func (m *MemoryRepo) PruneAbsences(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pruneAbsences(ctx, before)
+}
+
+func (m *MemoryRepo) ListAbsences(ctx context.Context, userID string) ([]model.Absence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.listAbsences(ctx, userID)
+}
+
+func (m *MemoryRepo) AbsentAt(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.absentAt(ctx, userIDs, at)
+}
+
+func (m *MemoryRepo) addAbsence(ctx context.Context, absence *model.Absence) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, ok := m.users[absence.UserID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.absences[absence.ID]; ok {
+		return ErrAbsenceExists
+	}
+
+	a := *absence
+	a.Start, a.End = a.Start.UTC(), a.End.UTC()
+	return m.mutate(opAddAbsence, a, func() {
+		m.applyAddAbsence(a)
+	})
+}
+
+func (m *MemoryRepo) applyAddAbsence(a model.Absence) {
+	m.tx.saveAbsence(m, a.ID)
+	m.absences[a.ID] = &a
+	m.indexAbsence(&a)
+}
+
+func (m *MemoryRepo) deleteAbsence(ctx context.Context, id string) (*model.Absence, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	a, ok := m.absences[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	deleted := *a
+
+	err := m.mutate(opDeleteAbsence, id, func() {
+		m.applyDeleteAbsence(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
+
+func (m *MemoryRepo) applyDeleteAbsence(id string) {
+	a, ok := m.absences[id]
+	if !ok {
+		return
+	}
+	m.tx.saveAbsence(m, id)
+	m.unindexAbsence(a)
+	delete(m.absences, id)
+}
+
+func (m *MemoryRepo) pruneAbsences(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var ids []string
+	for id, a := range m.absences {
+		if !a.End.After(before) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	sort.Strings(ids)
+
+	err := m.mutate(opPruneAbsences, ids, func() {
+		m.applyPruneAbsences(ids)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+func (m *MemoryRepo) applyPruneAbsences(ids []string) {
+	for _, id := range ids {
+		m.applyDeleteAbsence(id)
+	}
+}
+
+// indexAbsence и unindexAbsence ведут m.userAbsences, чтобы AbsentAt и
+// ListAbsences не просматривали отсутствия всех пользователей.
+func (m *MemoryRepo) indexAbsence(a *model.Absence) {
+	set, ok := m.userAbsences[a.UserID]
+	if !ok {
+		set = make(idSet)
+		m.userAbsences[a.UserID] = set
+	}
+	set[a.ID] = struct{}{}
+}
+
+func (m *MemoryRepo) unindexAbsence(a *model.Absence) {
+	set := m.userAbsences[a.UserID]
+	delete(set, a.ID)
+	if len(set) == 0 {
+		delete(m.userAbsences, a.UserID)
+	}
+}
+
+func (m *MemoryRepo) listAbsences(ctx context.Context, userID string) ([]model.Absence, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []model.Absence
+	for id := range m.userAbsences[userID] {
+		result = append(result, *m.absences[id])
+	}
+	sortAbsences(result)
+	return result, nil
+}
+
+func (m *MemoryRepo) absentAt(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	absent := make(map[string]bool)
+	for _, userID := range userIDs {
+		for id := range m.userAbsences[userID] {
+			if m.absences[id].Covers(at) {
+				absent[userID] = true
+				break
+			}
+		}
+	}
+	return absent, nil
+}
+
+// allAbsences возвращает копию всех отсутствий в порядке пользователя и начала.
+func (m *MemoryRepo) allAbsences() []model.Absence {
+	all := make([]model.Absence, 0, len(m.absences))
+	for _, a := range m.absences {
+		all = append(all, *a)
+	}
+	sortAbsences(all)
+	return all
+}
+
+func sortAbsences(as []model.Absence) {
+	sort.Slice(as, func(i, j int) bool {
+		a, b := as[i], as[j]
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.ID < b.ID
+	})
+}
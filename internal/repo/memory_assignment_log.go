@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+	"sort"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+func (m *MemoryRepo) AddAssignmentRecord(ctx context.Context, rec model.AssignmentRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addAssignmentRecord(ctx, rec)
+}
+
+func (m *MemoryRepo) ListAssignmentRecords(ctx context.Context, prID string) ([]model.AssignmentRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.listAssignmentRecords(ctx, prID)
+}
+
+func (m *MemoryRepo) addAssignmentRecord(ctx context.Context, rec model.AssignmentRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.mutate(opAddAssignmentRecord, rec, func() {
+		m.applyAddAssignmentRecord(rec)
+	})
+}
+
+func (m *MemoryRepo) applyAddAssignmentRecord(rec model.AssignmentRecord) {
+	m.tx.saveAssignmentLog(m, rec.PullRequestID)
+	rec.CreatedAt = rec.CreatedAt.UTC()
+	m.assignmentLog[rec.PullRequestID] = append(m.assignmentLog[rec.PullRequestID], rec)
+}
+
+func (m *MemoryRepo) listAssignmentRecords(ctx context.Context, prID string) ([]model.AssignmentRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return append([]model.AssignmentRecord(nil), m.assignmentLog[prID]...), nil
+}
+
+// allAssignmentRecords возвращает копию журналов назначений всех PR
+// в порядке id PR, внутри PR — в порядке добавления.
+func (m *MemoryRepo) allAssignmentRecords() []model.AssignmentRecord {
+	ids := make([]string, 0, len(m.assignmentLog))
+	for id := range m.assignmentLog {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var all []model.AssignmentRecord
+	for _, id := range ids {
+		all = append(all, m.assignmentLog[id]...)
+	}
+	return all
+}
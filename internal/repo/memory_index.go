@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+// Вторичные индексы MemoryRepo: ревьювер → PR и статус → PR.
+// Ведутся только через applyPutPullRequest и откат транзакции, поэтому
+// всегда соответствуют m.prs и m.reviewers.
+
+type idSet map[string]struct{}
+
+func (s idSet) sorted() []string {
+	ids := make([]string, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (m *MemoryRepo) indexPR(pr *model.PullRequest) {
+	for _, r := range pr.AssignedReviewers {
+		set, ok := m.byReviewer[r]
+		if !ok {
+			set = make(idSet)
+			m.byReviewer[r] = set
+		}
+		set[pr.ID] = struct{}{}
+	}
+
+	set, ok := m.byStatus[pr.Status]
+	if !ok {
+		set = make(idSet)
+		m.byStatus[pr.Status] = set
+	}
+	set[pr.ID] = struct{}{}
+}
+
+func (m *MemoryRepo) unindexPR(pr *model.PullRequest) {
+	for _, r := range pr.AssignedReviewers {
+		set := m.byReviewer[r]
+		delete(set, pr.ID)
+		if len(set) == 0 {
+			delete(m.byReviewer, r)
+		}
+	}
+
+	set := m.byStatus[pr.Status]
+	delete(set, pr.ID)
+	if len(set) == 0 {
+		delete(m.byStatus, pr.Status)
+	}
+}
+
+// pullRequestsByIDs возвращает копии PR в порядке id.
+func (m *MemoryRepo) pullRequestsByIDs(ctx context.Context, ids idSet) ([]model.PullRequest, error) {
+	result := make([]model.PullRequest, 0, len(ids))
+	for _, id := range ids.sorted() {
+		pr, err := m.getPullRequestByID(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *pr)
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+func (m *MemoryRepo) AddPairings(ctx context.Context, pairings []model.Pairing) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addPairings(ctx, pairings)
+}
+
+func (m *MemoryRepo) CountPairings(ctx context.Context, authorID string, reviewerIDs []string, since time.Time) (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.countPairings(ctx, authorID, reviewerIDs, since)
+}
+
+func (m *MemoryRepo) ListPairings(ctx context.Context) ([]model.Pairing, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.listPairings(ctx)
+}
+
+func (m *MemoryRepo) addPairings(ctx context.Context, pairings []model.Pairing) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(pairings) == 0 {
+		return nil
+	}
+
+	return m.mutate(opAddPairings, pairings, func() {
+		m.applyAddPairings(pairings)
+	})
+}
+
+func (m *MemoryRepo) applyAddPairings(pairings []model.Pairing) {
+	for _, p := range pairings {
+		m.tx.savePairings(m, p.AuthorID)
+		p.AssignedAt = p.AssignedAt.UTC()
+		m.pairings[p.AuthorID] = append(m.pairings[p.AuthorID], p)
+	}
+}
+
+func (m *MemoryRepo) countPairings(ctx context.Context, authorID string, reviewerIDs []string, since time.Time) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(reviewerIDs))
+	for _, id := range reviewerIDs {
+		wanted[id] = true
+	}
+
+	counts := make(map[string]int)
+	for _, p := range m.pairings[authorID] {
+		if wanted[p.ReviewerID] && !p.AssignedAt.Before(since) {
+			counts[p.ReviewerID]++
+		}
+	}
+	return counts, nil
+}
+
+func (m *MemoryRepo) listPairings(ctx context.Context) ([]model.Pairing, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.allPairings(), nil
+}
+
+// allPairings возвращает копию всех пар в порядке времени назначения.
+func (m *MemoryRepo) allPairings() []model.Pairing {
+	var all []model.Pairing
+	for _, ps := range m.pairings {
+		all = append(all, ps...)
+	}
+	sortPairings(all)
+	return all
+}
+
+func sortPairings(ps []model.Pairing) {
+	sort.SliceStable(ps, func(i, j int) bool {
+		a, b := ps[i], ps[j]
+		if !a.AssignedAt.Equal(b.AssignedAt) {
+			return a.AssignedAt.Before(b.AssignedAt)
+		}
+		if a.AuthorID != b.AuthorID {
+			return a.AuthorID < b.AuthorID
+		}
+		if a.PullRequestID != b.PullRequestID {
+			return a.PullRequestID < b.PullRequestID
+		}
+		return a.ReviewerID < b.ReviewerID
+	})
+}
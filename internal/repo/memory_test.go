@@ -0,0 +1,14 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo/repotest"
+)
+
+func TestMemoryRepo(t *testing.T) {
+	repotest.Run(t, func(tb testing.TB) repo.Repository {
+		return repo.NewMemoryRepo()
+	})
+}
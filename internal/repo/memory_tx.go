@@ -0,0 +1,447 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+// memoryTx — транзакция MemoryRepo. Всё время жизни транзакции держится
+// m.mu.Lock, так что операции внутри неё видят согласованное состояние и не
+// пересекаются с другими писателями. Перед первым изменением каждого ключа
+// запоминается его прежнее значение; при ошибке они возвращаются на место.
+// В журнал вся транзакция пишется одной записью при коммите.
+type memoryTx struct {
+	m    *MemoryRepo
+	done bool
+
+	// прежние значения; nil — ключа до транзакции не было
+	teams   map[string]*model.Team
+	users   map[string]*model.User
+	prs     map[string]*model.PullRequest
+	cursors map[string]*string
+	// число пар автора до транзакции: пары только добавляются
+	pairings map[string]int
+	// длина журнала назначений PR до транзакции: записи только добавляются
+	assignmentLog map[string]int
+	absences      map[string]*model.Absence
+
+	pending []batchEntry
+}
+
+func (m *MemoryRepo) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx := &memoryTx{
+		m:        m,
+		teams:    make(map[string]*model.Team),
+		users:    make(map[string]*model.User),
+		prs:      make(map[string]*model.PullRequest),
+		cursors:  make(map[string]*string),
+		pairings: make(map[string]int),
+
+		assignmentLog: make(map[string]int),
+		absences:      make(map[string]*model.Absence),
+	}
+	m.tx = tx
+
+	committed := false
+	defer func() {
+		if !committed {
+			tx.rollback()
+		}
+		tx.done = true
+		m.tx = nil
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	// контекст могли отменить, пока работала fn: тогда не коммитим
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := m.commitTx(tx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func (m *MemoryRepo) commitTx(tx *memoryTx) error {
+	if m.journal == nil || len(tx.pending) == 0 {
+		return nil
+	}
+
+	if err := m.journal.append(opBatch, tx.pending); err != nil {
+		return err
+	}
+	if m.journal.sinceSnapshot >= m.journal.snapshotEvery {
+		if err := m.compact(); err != nil {
+			log.Printf("memory repo: snapshot failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// record откладывает запись операции в журнал до коммита.
+func (tx *memoryTx) record(op string, data any) error {
+	if tx.m.journal == nil {
+		return nil
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	tx.pending = append(tx.pending, batchEntry{Op: op, Data: payload})
+	return nil
+}
+
+func (tx *memoryTx) saveTeam(m *MemoryRepo, name string) {
+	if tx == nil {
+		return
+	}
+	if _, saved := tx.teams[name]; !saved {
+		tx.teams[name] = m.teams[name]
+	}
+}
+
+func (tx *memoryTx) saveUser(m *MemoryRepo, id string) {
+	if tx == nil {
+		return
+	}
+	if _, saved := tx.users[id]; !saved {
+		tx.users[id] = m.users[id]
+	}
+}
+
+func (tx *memoryTx) savePR(m *MemoryRepo, id string) {
+	if tx == nil {
+		return
+	}
+	if _, saved := tx.prs[id]; !saved {
+		tx.prs[id] = m.prs[id]
+	}
+}
+
+func (tx *memoryTx) saveCursor(m *MemoryRepo, team string) {
+	if tx == nil {
+		return
+	}
+	if _, saved := tx.cursors[team]; !saved {
+		if c, ok := m.cursors[team]; ok {
+			tx.cursors[team] = &c
+		} else {
+			tx.cursors[team] = nil
+		}
+	}
+}
+
+func (tx *memoryTx) savePairings(m *MemoryRepo, author string) {
+	if tx == nil {
+		return
+	}
+	if _, saved := tx.pairings[author]; !saved {
+		tx.pairings[author] = len(m.pairings[author])
+	}
+}
+
+func (tx *memoryTx) saveAssignmentLog(m *MemoryRepo, prID string) {
+	if tx == nil {
+		return
+	}
+	if _, saved := tx.assignmentLog[prID]; !saved {
+		tx.assignmentLog[prID] = len(m.assignmentLog[prID])
+	}
+}
+
+func (tx *memoryTx) saveAbsence(m *MemoryRepo, id string) {
+	if tx == nil {
+		return
+	}
+	if _, saved := tx.absences[id]; !saved {
+		tx.absences[id] = m.absences[id]
+	}
+}
+
+func (tx *memoryTx) rollback() {
+	m := tx.m
+	for name, t := range tx.teams {
+		if t == nil {
+			delete(m.teams, name)
+		} else {
+			m.teams[name] = t
+		}
+	}
+	for id, u := range tx.users {
+		if u == nil {
+			delete(m.users, id)
+		} else {
+			m.users[id] = u
+		}
+	}
+	for id, pr := range tx.prs {
+		if current, ok := m.prs[id]; ok {
+			m.unindexPR(current)
+		}
+		if pr == nil {
+			delete(m.prs, id)
+			delete(m.reviewers, id)
+		} else {
+			m.prs[id] = pr
+			m.reviewers[id] = pr.AssignedReviewers
+			m.indexPR(pr)
+		}
+	}
+	for team, c := range tx.cursors {
+		if c == nil {
+			delete(m.cursors, team)
+		} else {
+			m.cursors[team] = *c
+		}
+	}
+	for author, n := range tx.pairings {
+		if n == 0 {
+			delete(m.pairings, author)
+		} else {
+			m.pairings[author] = m.pairings[author][:n]
+		}
+	}
+	for prID, n := range tx.assignmentLog {
+		if n == 0 {
+			delete(m.assignmentLog, prID)
+		} else {
+			m.assignmentLog[prID] = m.assignmentLog[prID][:n]
+		}
+	}
+	for id, a := range tx.absences {
+		if cur, ok := m.absences[id]; ok {
+			m.unindexAbsence(cur)
+		}
+		if a == nil {
+			delete(m.absences, id)
+		} else {
+			m.absences[id] = a
+			m.indexAbsence(a)
+		}
+	}
+}
+
+func (tx *memoryTx) check() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	return nil
+}
+
+// Вложенная транзакция выполняется в рамках внешней.
+func (tx *memoryTx) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return fn(tx)
+}
+
+func (tx *memoryTx) CreateTeam(ctx context.Context, team *model.Team) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.m.createTeam(ctx, team)
+}
+
+func (tx *memoryTx) GetTeam(ctx context.Context, name string) (*model.Team, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.getTeam(ctx, name)
+}
+
+func (tx *memoryTx) SetUserActive(ctx context.Context, userID string, isActive bool) (*model.User, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.setUserActive(ctx, userID, isActive)
+}
+
+func (tx *memoryTx) SetUserSettings(ctx context.Context, userID string, settings model.UserSettings) (*model.User, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.setUserSettings(ctx, userID, settings)
+}
+
+func (tx *memoryTx) GetUserByID(ctx context.Context, userID string) (*model.User, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.getUserByID(ctx, userID)
+}
+
+func (tx *memoryTx) CreatePullRequest(ctx context.Context, pr *model.PullRequest) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.m.createPullRequest(ctx, pr)
+}
+
+func (tx *memoryTx) GetPullRequestByID(ctx context.Context, id string) (*model.PullRequest, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.getPullRequestByID(ctx, id)
+}
+
+func (tx *memoryTx) UpdatePullRequest(ctx context.Context, pr *model.PullRequest) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.m.updatePullRequest(ctx, pr)
+}
+
+func (tx *memoryTx) SetReviewers(ctx context.Context, prID string, reviewers []string) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.m.setReviewers(ctx, prID, reviewers)
+}
+
+func (tx *memoryTx) GetReviewers(ctx context.Context, prID string) ([]string, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.getReviewers(ctx, prID)
+}
+
+func (tx *memoryTx) GetPullRequestsByReviewer(ctx context.Context, userID string) ([]model.PullRequest, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.getPullRequestsByReviewer(ctx, userID)
+}
+
+func (tx *memoryTx) GetPullRequestsByStatus(ctx context.Context, status model.PRStatus) ([]model.PullRequest, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.getPullRequestsByStatus(ctx, status)
+}
+
+func (tx *memoryTx) ListTeams(ctx context.Context) ([]model.Team, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.listTeams(ctx)
+}
+
+func (tx *memoryTx) ListPullRequests(ctx context.Context) ([]model.PullRequest, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.listPullRequests(ctx)
+}
+
+func (tx *memoryTx) SetTeamSettings(ctx context.Context, name string, settings model.TeamSettings) (*model.Team, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.setTeamSettings(ctx, name, settings)
+}
+
+func (tx *memoryTx) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.countOpenReviews(ctx, userIDs)
+}
+
+func (tx *memoryTx) GetRotationCursor(ctx context.Context, teamName string) (string, error) {
+	if err := tx.check(); err != nil {
+		return "", err
+	}
+	return tx.m.getRotationCursor(ctx, teamName)
+}
+
+func (tx *memoryTx) SetRotationCursor(ctx context.Context, teamName, userID string) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.m.setRotationCursor(ctx, teamName, userID)
+}
+
+func (tx *memoryTx) AddPairings(ctx context.Context, pairings []model.Pairing) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.m.addPairings(ctx, pairings)
+}
+
+func (tx *memoryTx) CountPairings(ctx context.Context, authorID string, reviewerIDs []string, since time.Time) (map[string]int, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.countPairings(ctx, authorID, reviewerIDs, since)
+}
+
+func (tx *memoryTx) ListPairings(ctx context.Context) ([]model.Pairing, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.listPairings(ctx)
+}
+
+func (tx *memoryTx) AddAssignmentRecord(ctx context.Context, rec model.AssignmentRecord) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.m.addAssignmentRecord(ctx, rec)
+}
+
+func (tx *memoryTx) ListAssignmentRecords(ctx context.Context, prID string) ([]model.AssignmentRecord, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.listAssignmentRecords(ctx, prID)
+}
+
+func (tx *memoryTx) AddAbsence(ctx context.Context, absence *model.Absence) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.m.addAbsence(ctx, absence)
+}
+
+func (tx *memoryTx) DeleteAbsence(ctx context.Context, id string) (*model.Absence, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.deleteAbsence(ctx, id)
+}
+
+func (tx *memoryTx) PruneAbsences(ctx context.Context, before time.Time) (int, error) {
+	if err := tx.check(); err != nil {
+		return 0, err
+	}
+	return tx.m.pruneAbsences(ctx, before)
+}
+
+func (tx *memoryTx) ListAbsences(ctx context.Context, userID string) ([]model.Absence, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.listAbsences(ctx, userID)
+}
+
+func (tx *memoryTx) AbsentAt(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.m.absentAt(ctx, userIDs, at)
+}
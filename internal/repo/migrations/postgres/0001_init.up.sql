@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS teams (
+	name TEXT PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id        TEXT PRIMARY KEY,
+	username  TEXT NOT NULL,
+	team_name TEXT NOT NULL REFERENCES teams (name),
+	is_active BOOLEAN NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS users_team_name_idx ON users (team_name);
+
+CREATE TABLE IF NOT EXISTS pull_requests (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	author_id  TEXT NOT NULL,
+	status     TEXT NOT NULL,
+	created_at TIMESTAMPTZ,
+	merged_at  TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS pr_reviewers (
+	pr_id    TEXT NOT NULL REFERENCES pull_requests (id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	user_id  TEXT NOT NULL,
+	PRIMARY KEY (pr_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS pr_reviewers_user_id_idx ON pr_reviewers (user_id);
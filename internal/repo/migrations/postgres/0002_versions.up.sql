@@ -0,0 +1,2 @@
+ALTER TABLE teams ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE pull_requests ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS pull_requests_status_idx ON pull_requests (status);
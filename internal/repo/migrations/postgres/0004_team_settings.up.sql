@@ -0,0 +1 @@
+ALTER TABLE teams ADD COLUMN settings TEXT NOT NULL DEFAULT '{}';
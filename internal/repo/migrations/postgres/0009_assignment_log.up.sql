@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS assignment_log (
+	pr_id      TEXT NOT NULL,
+	position   INTEGER NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	record     TEXT NOT NULL,
+	PRIMARY KEY (pr_id, position)
+);
@@ -0,0 +1 @@
+ALTER TABLE pull_requests ADD COLUMN shadow_reviewer TEXT NOT NULL DEFAULT '';
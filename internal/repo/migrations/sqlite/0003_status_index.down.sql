@@ -0,0 +1 @@
+DROP INDEX IF EXISTS pull_requests_status_idx;
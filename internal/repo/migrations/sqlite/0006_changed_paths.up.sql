@@ -0,0 +1 @@
+ALTER TABLE pull_requests ADD COLUMN changed_paths TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN settings TEXT NOT NULL DEFAULT '{}';
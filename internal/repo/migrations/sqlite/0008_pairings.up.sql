@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS pairings (
+	author_id   TEXT NOT NULL,
+	reviewer_id TEXT NOT NULL,
+	pr_id       TEXT NOT NULL,
+	assigned_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pairings_author_id_idx ON pairings (author_id, assigned_at);
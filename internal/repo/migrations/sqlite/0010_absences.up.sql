@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS absences (
+	id        TEXT PRIMARY KEY,
+	user_id   TEXT NOT NULL,
+	starts_at TIMESTAMP NOT NULL,
+	ends_at   TIMESTAMP NOT NULL,
+	reason    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS absences_user_id_idx ON absences (user_id, ends_at);
@@ -0,0 +1 @@
+ALTER TABLE pull_requests ADD COLUMN excluded_reviewers TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq"
+)
+
+type PostgresRepo struct {
+	sqlRepo
+}
+
+func NewPostgresRepo(db *sql.DB) *PostgresRepo {
+	return &PostgresRepo{newSQLRepo(db, dialectPostgres)}
+}
+
+// OpenPostgres подключается по dsn. Схему создают миграции (см. MigrateUp).
+func OpenPostgres(dsn string) (*PostgresRepo, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return NewPostgresRepo(db), nil
+}
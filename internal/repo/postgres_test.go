@@ -0,0 +1,72 @@
+package repo_test
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo/repotest"
+)
+
+// TestPostgresRepo прогоняет набор на Postgres из PG_TEST_DSN
+// (см. make test-postgres). Без переменной тест пропускается.
+func TestPostgresRepo(t *testing.T) {
+	dsn := os.Getenv("PG_TEST_DSN")
+	if dsn == "" {
+		t.Skip("PG_TEST_DSN is not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer admin.Close()
+	if err := admin.Ping(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	var n atomic.Int64
+	repotest.Run(t, func(tb testing.TB) repo.Repository {
+		// каждому вызову — своя пустая схема
+		schema := fmt.Sprintf("repotest_%d_%d", os.Getpid(), n.Add(1))
+		if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+			tb.Fatalf("create schema: %v", err)
+		}
+		tb.Cleanup(func() {
+			if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+				tb.Errorf("drop schema: %v", err)
+			}
+		})
+
+		r, err := repo.OpenPostgres(withSearchPath(dsn, schema))
+		if err != nil {
+			tb.Fatalf("OpenPostgres: %v", err)
+		}
+		tb.Cleanup(func() { r.Close() })
+
+		if _, err := r.MigrateUp(); err != nil {
+			tb.Fatalf("MigrateUp: %v", err)
+		}
+		return r
+	})
+}
+
+// withSearchPath добавляет к dsn схему по умолчанию; dsn — URL
+// или строка key=value.
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
@@ -1,23 +1,102 @@
 package repo
 
-import "github.com/iamyblitz/pr-reviewer-service/internal/model"
+import (
+	"context"
+	"time"
 
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+// Repository — хранилище команд, пользователей и PR. Все методы принимают
+// контекст и прекращают работу с ctx.Err(), если он отменён.
 type Repository interface {
 	// Teams
-	CreateTeam(team *model.Team) error
-	GetTeam(name string) (*model.Team, error)
+	CreateTeam(ctx context.Context, team *model.Team) error
+	GetTeam(ctx context.Context, name string) (*model.Team, error)
+	// ListTeams возвращает все команды с участниками в порядке имени.
+	ListTeams(ctx context.Context) ([]model.Team, error)
+	// SetTeamSettings заменяет настройки команды и увеличивает её версию.
+	SetTeamSettings(ctx context.Context, name string, settings model.TeamSettings) (*model.Team, error)
+	// GetRotationCursor и SetRotationCursor хранят id последнего ревьювера,
+	// назначенного по кругу в команде ("" — ещё никого). Версию команды не меняют.
+	GetRotationCursor(ctx context.Context, teamName string) (string, error)
+	SetRotationCursor(ctx context.Context, teamName, userID string) error
 
 	// Users
-	SetUserActive(userID string, isActive bool) (*model.User, error)
-	GetUserByID(userID string) (*model.User, error)
+	SetUserActive(ctx context.Context, userID string, isActive bool) (*model.User, error)
+	// SetUserSettings заменяет настройки пользователя и увеличивает версию его команды.
+	SetUserSettings(ctx context.Context, userID string, settings model.UserSettings) (*model.User, error)
+	GetUserByID(ctx context.Context, userID string) (*model.User, error)
 
 	// Pull Requests
-	CreatePullRequest(pr *model.PullRequest) error
-	GetPullRequestByID(id string) (*model.PullRequest, error)
-	UpdatePullRequest(pr *model.PullRequest) error
+	// CreatePullRequest сохраняет PR с версией 1; UpdatePullRequest принимает
+	// только pr.Version, совпадающую с сохранённой (иначе ErrVersionConflict),
+	// и увеличивает её. Обе записывают новую версию в pr.Version.
+	CreatePullRequest(ctx context.Context, pr *model.PullRequest) error
+	GetPullRequestByID(ctx context.Context, id string) (*model.PullRequest, error)
+	UpdatePullRequest(ctx context.Context, pr *model.PullRequest) error
+	GetPullRequestsByStatus(ctx context.Context, status model.PRStatus) ([]model.PullRequest, error)
+	// ListPullRequests возвращает все PR с ревьюверами в порядке id.
+	ListPullRequests(ctx context.Context) ([]model.PullRequest, error)
 
 	// Reviewers
-	SetReviewers(prID string, reviewers []string) error
-	GetReviewers(prID string) ([]string, error)
-	GetPullRequestsByReviewer(userID string) ([]model.PullRequest, error)
+	SetReviewers(ctx context.Context, prID string, reviewers []string) error
+	GetReviewers(ctx context.Context, prID string) ([]string, error)
+	GetPullRequestsByReviewer(ctx context.Context, userID string) ([]model.PullRequest, error)
+	// CountOpenReviews возвращает число открытых PR, где пользователь — ревьювер.
+	// Пользователей без открытых ревью в результате нет. Внутри WithTx
+	// пользователи блокируются до конца транзакции, поэтому проверка лимита
+	// и назначение в ней не пересекаются с параллельными.
+	CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error)
+
+	// Pairings
+	// AddPairings сохраняет, кто чей PR ревьюил и когда; записи только добавляются.
+	AddPairings(ctx context.Context, pairings []model.Pairing) error
+	// CountPairings возвращает, сколько раз каждый из reviewerIDs назначался
+	// на PR автора authorID не раньше since. Ревьюверов без пар в результате нет.
+	CountPairings(ctx context.Context, authorID string, reviewerIDs []string, since time.Time) (map[string]int, error)
+	// ListPairings возвращает все пары в порядке времени назначения.
+	ListPairings(ctx context.Context) ([]model.Pairing, error)
+
+	// Assignment log
+	// AddAssignmentRecord дописывает запись в журнал назначений PR.
+	AddAssignmentRecord(ctx context.Context, rec model.AssignmentRecord) error
+	// ListAssignmentRecords возвращает журнал назначений PR в порядке добавления.
+	ListAssignmentRecords(ctx context.Context, prID string) ([]model.AssignmentRecord, error)
+
+	// Absences
+	// AddAbsence сохраняет отсутствие пользователя: ErrNotFound, если
+	// пользователя нет, ErrAbsenceExists, если id уже занят.
+	AddAbsence(ctx context.Context, absence *model.Absence) error
+	// DeleteAbsence удаляет отсутствие и возвращает его; ErrNotFound, если его нет.
+	DeleteAbsence(ctx context.Context, id string) (*model.Absence, error)
+	// PruneAbsences удаляет отсутствия, закончившиеся не позже before,
+	// и возвращает, сколько удалено.
+	PruneAbsences(ctx context.Context, before time.Time) (int, error)
+	// ListAbsences возвращает отсутствия пользователя в порядке начала.
+	ListAbsences(ctx context.Context, userID string) ([]model.Absence, error)
+	// AbsentAt возвращает, кто из userIDs отсутствует в момент at.
+	// Присутствующих в результате нет.
+	AbsentAt(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error)
+
+	// Transactions
+	// WithTx выполняет fn атомарно: все чтения и записи через tx видят
+	// согласованное состояние, а при ошибке из fn ни одно изменение не сохраняется.
+	WithTx(ctx context.Context, fn func(tx Repository) error) error
+}
+
+type snapshotKey struct{}
+
+// WithSnapshot помечает ctx для WithTx: транзакция только читает и все её
+// запросы видят данные на момент её начала (в Postgres — REPEATABLE READ
+// без блокировки строк). Нужна, когда состояние читается многими запросами,
+// например при экспорте. В памяти и в SQLite это и так верно для любой
+// транзакции: они не пускают параллельных писателей.
+func WithSnapshot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, snapshotKey{}, true)
+}
+
+func isSnapshot(ctx context.Context) bool {
+	snapshot, _ := ctx.Value(snapshotKey{}).(bool)
+	return snapshot
 }
@@ -0,0 +1,62 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// Размеры истории PR для бенчмарков выборок.
+var benchSizes = []int{1_000, 10_000, 50_000}
+
+const benchUsers = 200
+
+// BenchmarkGetPullRequestsByReviewer меряет /users/getReview: выборку PR
+// одного ревьювера при растущем числе PR в хранилище.
+func BenchmarkGetPullRequestsByReviewer(b *testing.B, newRepo Factory) {
+	for _, n := range benchSizes {
+		// b.Run вызывает функцию несколько раз с растущим b.N,
+		// поэтому хранилище заполняется один раз на размер
+		r := newRepo(b)
+		seedBench(b, r, n)
+
+		b.Run(fmt.Sprintf("prs=%d", n), func(b *testing.B) {
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				user := fmt.Sprintf("u%03d", i%benchUsers)
+				if _, err := r.GetPullRequestsByReviewer(ctx, user); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// seedBench создаёт команду из benchUsers человек и n PR по два ревьювера,
+// каждый десятый PR смёржен.
+func seedBench(b *testing.B, r repo.Repository, n int) {
+	b.Helper()
+
+	team := &model.Team{Name: "bench"}
+	for i := 0; i < benchUsers; i++ {
+		team.Members = append(team.Members, model.User{ID: fmt.Sprintf("u%03d", i), IsActive: true})
+	}
+	mustCreateTeam(b, r, team)
+
+	for i := 0; i < n; i++ {
+		pr := openPR(fmt.Sprintf("pr-%06d", i),
+			fmt.Sprintf("u%03d", i%benchUsers),
+			fmt.Sprintf("u%03d", (i+1)%benchUsers),
+			fmt.Sprintf("u%03d", (i+7)%benchUsers))
+		if i%10 == 0 {
+			pr.Status = model.PRStatusMerged
+		}
+		mustCreatePR(b, r, pr)
+	}
+}
@@ -0,0 +1,121 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// testMigrations откатывает все миграции и применяет их заново: down-скрипты
+// должны убирать схему целиком, а schema_migrations — следовать за ними.
+// Хранилища без схемы пропускаются.
+func testMigrations(t *testing.T, newRepo Factory) {
+	r := newRepo(t)
+	m, ok := r.(repo.Migrator)
+	if !ok {
+		t.Skip("storage has no migrations")
+	}
+	ctx := context.Background()
+
+	status := mustMigrationStatus(t, m)
+	for _, st := range status {
+		if !st.Applied || st.AppliedAt == nil {
+			t.Fatalf("migration %04d_%s is not applied by factory", st.Version, st.Name)
+		}
+	}
+
+	t.Run("DownOne", func(t *testing.T) {
+		last := status[len(status)-1]
+		down, err := m.MigrateDown(1)
+		if err != nil {
+			t.Fatalf("MigrateDown(1): %v", err)
+		}
+		if len(down) != 1 || down[0].Version != last.Version {
+			t.Fatalf("MigrateDown(1) rolled back %v, want %04d", versions(down), last.Version)
+		}
+		if st := mustMigrationStatus(t, m); st[len(st)-1].Applied {
+			t.Errorf("migration %04d still recorded as applied", last.Version)
+		}
+
+		up, err := m.MigrateUp()
+		if err != nil {
+			t.Fatalf("MigrateUp: %v", err)
+		}
+		if len(up) != 1 || up[0].Version != last.Version {
+			t.Errorf("MigrateUp applied %v, want %04d", versions(up), last.Version)
+		}
+	})
+
+	t.Run("DownToZero", func(t *testing.T) {
+		mustCreateTeam(t, r, backend())
+
+		down, err := m.MigrateDown(len(status))
+		if err != nil {
+			t.Fatalf("MigrateDown(%d): %v", len(status), err)
+		}
+		if len(down) != len(status) {
+			t.Fatalf("MigrateDown rolled back %v, want all %d", versions(down), len(status))
+		}
+		for i, mg := range down {
+			if want := status[len(status)-1-i].Version; mg.Version != want {
+				t.Fatalf("MigrateDown order %v, want newest first", versions(down))
+			}
+		}
+		for _, st := range mustMigrationStatus(t, m) {
+			if st.Applied {
+				t.Errorf("migration %04d_%s still recorded as applied", st.Version, st.Name)
+			}
+		}
+		if _, err := r.ListTeams(ctx); err == nil {
+			t.Errorf("ListTeams succeeded without schema")
+		}
+		if more, err := m.MigrateDown(1); err != nil || len(more) != 0 {
+			t.Errorf("MigrateDown at zero = %v, %v, want nothing", versions(more), err)
+		}
+
+		up, err := m.MigrateUp()
+		if err != nil {
+			t.Fatalf("MigrateUp: %v", err)
+		}
+		if len(up) != len(status) {
+			t.Fatalf("MigrateUp applied %v, want all %d", versions(up), len(status))
+		}
+		for i, st := range mustMigrationStatus(t, m) {
+			if !st.Applied || st.AppliedAt == nil || st.Version != status[i].Version {
+				t.Errorf("after up: migration %04d_%s applied=%v", st.Version, st.Name, st.Applied)
+			}
+		}
+
+		// схема снова рабочая и пустая
+		teams, err := r.ListTeams(ctx)
+		if err != nil {
+			t.Fatalf("ListTeams: %v", err)
+		}
+		if len(teams) != 0 {
+			t.Errorf("got %d teams after re-migration, want none", len(teams))
+		}
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2"))
+	})
+}
+
+func mustMigrationStatus(t *testing.T, m repo.Migrator) []repo.MigrationStatus {
+	t.Helper()
+	status, err := m.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	if len(status) == 0 {
+		t.Fatalf("no migrations")
+	}
+	return status
+}
+
+func versions(ms []repo.Migration) []int {
+	result := make([]int, 0, len(ms))
+	for _, m := range ms {
+		result = append(result, m.Version)
+	}
+	return result
+}
@@ -0,0 +1,1736 @@
+// Package repotest — общий набор проверок для реализаций repo.Repository.
+//
+// Каждый бэкенд подключает его из своего теста:
+//
+//	func TestMemoryRepo(t *testing.T) {
+//		repotest.Run(t, func(tb testing.TB) repo.Repository {
+//			return repo.NewMemoryRepo()
+//		})
+//	}
+//
+// Фабрика должна на каждый вызов возвращать пустое хранилище.
+// Той же фабрикой запускаются бенчмарки (см. bench.go).
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+type Factory func(tb testing.TB) repo.Repository
+
+func Run(t *testing.T, newRepo Factory) {
+	t.Run("Teams", func(t *testing.T) { testTeams(t, newRepo) })
+	t.Run("Users", func(t *testing.T) { testUsers(t, newRepo) })
+	t.Run("PullRequests", func(t *testing.T) { testPullRequests(t, newRepo) })
+	t.Run("Reviewers", func(t *testing.T) { testReviewers(t, newRepo) })
+	t.Run("CopyOnRead", func(t *testing.T) { testCopyOnRead(t, newRepo) })
+	t.Run("Concurrency", func(t *testing.T) { testConcurrency(t, newRepo) })
+	t.Run("Transactions", func(t *testing.T) { testTransactions(t, newRepo) })
+	t.Run("Versions", func(t *testing.T) { testVersions(t, newRepo) })
+	t.Run("Context", func(t *testing.T) { testContext(t, newRepo) })
+	t.Run("Pairings", func(t *testing.T) { testPairings(t, newRepo) })
+	t.Run("AssignmentLog", func(t *testing.T) { testAssignmentLog(t, newRepo) })
+	t.Run("Absences", func(t *testing.T) { testAbsences(t, newRepo) })
+	t.Run("Migrations", func(t *testing.T) { testMigrations(t, newRepo) })
+}
+
+var (
+	createdAt = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	mergedAt  = time.Date(2024, 5, 2, 12, 30, 0, 0, time.UTC)
+)
+
+func backend() *model.Team {
+	return &model.Team{
+		Name: "backend",
+		Members: []model.User{
+			{ID: "u1", Username: "Alice", IsActive: true},
+			{ID: "u2", Username: "Bob", IsActive: true},
+			{ID: "u3", Username: "Carol", IsActive: false},
+		},
+	}
+}
+
+func openPR(id, author string, reviewers ...string) *model.PullRequest {
+	at := createdAt
+	return &model.PullRequest{
+		ID:                id,
+		Name:              "PR " + id,
+		AuthorID:          author,
+		Status:            model.PRStatusOpen,
+		AssignedReviewers: reviewers,
+		CreatedAt:         &at,
+	}
+}
+
+func mustCreateTeam(t testing.TB, r repo.Repository, team *model.Team) {
+	t.Helper()
+	if err := r.CreateTeam(context.Background(), team); err != nil {
+		t.Fatalf("CreateTeam(%q): %v", team.Name, err)
+	}
+}
+
+func mustCreatePR(t testing.TB, r repo.Repository, pr *model.PullRequest) {
+	t.Helper()
+	if err := r.CreatePullRequest(context.Background(), pr); err != nil {
+		t.Fatalf("CreatePullRequest(%q): %v", pr.ID, err)
+	}
+}
+
+func testTeams(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		got, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		if got.Name != "backend" {
+			t.Errorf("Name = %q, want %q", got.Name, "backend")
+		}
+
+		members := sortedUsers(got.Members)
+		want := []model.User{
+			{ID: "u1", Username: "Alice", TeamName: "backend", IsActive: true},
+			{ID: "u2", Username: "Bob", TeamName: "backend", IsActive: true},
+			{ID: "u3", Username: "Carol", TeamName: "backend", IsActive: false},
+		}
+		if fmt.Sprint(members) != fmt.Sprint(want) {
+			t.Errorf("Members = %+v, want %+v", members, want)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		err := r.CreateTeam(ctx, &model.Team{Name: "backend"})
+		if !errors.Is(err, repo.ErrTeamExists) {
+			t.Fatalf("CreateTeam duplicate: err = %v, want ErrTeamExists", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo(t)
+		if _, err := r.GetTeam(ctx, "nope"); !errors.Is(err, repo.ErrNotFound) {
+			t.Fatalf("GetTeam missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("EmptyTeam", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, &model.Team{Name: "empty"})
+
+		got, err := r.GetTeam(ctx, "empty")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		if len(got.Members) != 0 {
+			t.Errorf("Members = %+v, want none", got.Members)
+		}
+	})
+
+	t.Run("UserMovesToNewTeam", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreateTeam(t, r, &model.Team{
+			Name:    "frontend",
+			Members: []model.User{{ID: "u2", Username: "Bob", IsActive: true}},
+		})
+
+		u, err := r.GetUserByID(ctx, "u2")
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if u.TeamName != "frontend" {
+			t.Errorf("TeamName = %q, want %q", u.TeamName, "frontend")
+		}
+
+		old, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		if ids := userIDs(old.Members); fmt.Sprint(ids) != "[u1 u3]" {
+			t.Errorf("backend members = %v, want [u1 u3]", ids)
+		}
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		r := newRepo(t)
+		team := backend()
+		team.Settings = model.TeamSettings{ReviewerStrategy: "random"}
+		mustCreateTeam(t, r, team)
+
+		got, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		if !reflect.DeepEqual(got.Settings, team.Settings) {
+			t.Errorf("Settings = %+v, want %+v", got.Settings, team.Settings)
+		}
+
+		want := model.TeamSettings{ReviewerStrategy: "other"}
+		updated, err := r.SetTeamSettings(ctx, "backend", want)
+		if err != nil {
+			t.Fatalf("SetTeamSettings: %v", err)
+		}
+		if !reflect.DeepEqual(updated.Settings, want) || updated.Version != got.Version+1 {
+			t.Errorf("SetTeamSettings = %+v (version %d), want %+v (version %d)",
+				updated.Settings, updated.Version, want, got.Version+1)
+		}
+		if len(updated.Members) != 3 {
+			t.Errorf("SetTeamSettings returned %d members, want 3", len(updated.Members))
+		}
+
+		teams, err := r.ListTeams(ctx)
+		if err != nil {
+			t.Fatalf("ListTeams: %v", err)
+		}
+		if len(teams) != 1 || !reflect.DeepEqual(teams[0].Settings, want) {
+			t.Errorf("ListTeams settings = %+v, want %+v", teams, want)
+		}
+
+		err = r.WithTx(ctx, func(tx repo.Repository) error {
+			if _, err := tx.SetTeamSettings(ctx, "backend", model.TeamSettings{}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithTx: err = %v, want errAbort", err)
+		}
+		if got, err := r.GetTeam(ctx, "backend"); err != nil || !reflect.DeepEqual(got.Settings, want) {
+			t.Errorf("GetTeam after rollback = %+v, %v; want settings %+v", got, err, want)
+		}
+
+		if _, err := r.SetTeamSettings(ctx, "nope", want); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("SetTeamSettings missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("RotationCursor", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		if c, err := r.GetRotationCursor(ctx, "backend"); err != nil || c != "" {
+			t.Errorf("GetRotationCursor on new team = %q, %v; want empty", c, err)
+		}
+		if err := r.SetRotationCursor(ctx, "backend", "u2"); err != nil {
+			t.Fatalf("SetRotationCursor: %v", err)
+		}
+		if c, err := r.GetRotationCursor(ctx, "backend"); err != nil || c != "u2" {
+			t.Errorf("GetRotationCursor = %q, %v; want u2", c, err)
+		}
+		if team, err := r.GetTeam(ctx, "backend"); err != nil || team.Version != 1 {
+			t.Errorf("GetTeam after SetRotationCursor = %+v, %v; want version 1", team, err)
+		}
+
+		err := r.WithTx(ctx, func(tx repo.Repository) error {
+			if err := tx.SetRotationCursor(ctx, "backend", "u3"); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithTx: err = %v, want errAbort", err)
+		}
+		if c, err := r.GetRotationCursor(ctx, "backend"); err != nil || c != "u2" {
+			t.Errorf("GetRotationCursor after rollback = %q, %v; want u2", c, err)
+		}
+
+		if _, err := r.GetRotationCursor(ctx, "nope"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetRotationCursor missing: err = %v, want ErrNotFound", err)
+		}
+		if err := r.SetRotationCursor(ctx, "nope", "u1"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("SetRotationCursor missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ShadowReviewer", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		pr := openPR("pr-1", "u1", "u2")
+		pr.ShadowReviewer = "u3"
+		mustCreatePR(t, r, pr)
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, got, pr)
+
+		got.ShadowReviewer = ""
+		if err := r.UpdatePullRequest(ctx, got); err != nil {
+			t.Fatalf("UpdatePullRequest: %v", err)
+		}
+		again, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, again, got)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		r := newRepo(t)
+
+		teams, err := r.ListTeams(ctx)
+		if err != nil {
+			t.Fatalf("ListTeams on empty repo: %v", err)
+		}
+		if len(teams) != 0 {
+			t.Errorf("ListTeams on empty repo = %d teams, want none", len(teams))
+		}
+
+		mustCreateTeam(t, r, &model.Team{
+			Name:    "frontend",
+			Members: []model.User{{ID: "u2", Username: "Bob", IsActive: true}},
+		})
+		mustCreateTeam(t, r, backend())
+		mustCreateTeam(t, r, &model.Team{Name: "empty"})
+
+		teams, err = r.ListTeams(ctx)
+		if err != nil {
+			t.Fatalf("ListTeams: %v", err)
+		}
+		got := make([]string, 0, len(teams))
+		for _, team := range teams {
+			got = append(got, fmt.Sprintf("%s%v", team.Name, userIDs(team.Members)))
+		}
+		// u2 перешёл в backend, созданную позже
+		if want := "[backend[u1 u2 u3] empty[] frontend[]]"; fmt.Sprint(got) != want {
+			t.Errorf("ListTeams = %v, want %s", got, want)
+		}
+	})
+}
+
+func testUsers(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("GetUserByID", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		u, err := r.GetUserByID(ctx, "u1")
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		want := model.User{ID: "u1", Username: "Alice", TeamName: "backend", IsActive: true}
+		if !reflect.DeepEqual(*u, want) {
+			t.Errorf("user = %+v, want %+v", *u, want)
+		}
+
+		if _, err := r.GetUserByID(ctx, "nope"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetUserByID missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("SetUserActive", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		u, err := r.SetUserActive(ctx, "u1", false)
+		if err != nil {
+			t.Fatalf("SetUserActive: %v", err)
+		}
+		if u.IsActive || u.ID != "u1" || u.TeamName != "backend" {
+			t.Errorf("SetUserActive returned %+v", *u)
+		}
+
+		got, err := r.GetUserByID(ctx, "u1")
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if got.IsActive {
+			t.Error("GetUserByID: IsActive = true after SetUserActive(false)")
+		}
+
+		team, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		for _, m := range team.Members {
+			if m.ID == "u1" && m.IsActive {
+				t.Error("GetTeam: member u1 still active after SetUserActive(false)")
+			}
+		}
+
+		if _, err := r.SetUserActive(ctx, "nope", true); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("SetUserActive missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		r := newRepo(t)
+		team := backend()
+		team.Members[1].Settings = model.UserSettings{MaxOpenReviews: 3}
+		mustCreateTeam(t, r, team)
+
+		got, err := r.GetUserByID(ctx, "u2")
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if !reflect.DeepEqual(got.Settings, team.Members[1].Settings) {
+			t.Errorf("GetUserByID: Settings = %+v, want %+v", got.Settings, team.Members[1].Settings)
+		}
+
+		want := model.UserSettings{MaxOpenReviews: 5}
+		u, err := r.SetUserSettings(ctx, "u1", want)
+		if err != nil {
+			t.Fatalf("SetUserSettings: %v", err)
+		}
+		if u.ID != "u1" || u.TeamName != "backend" || !u.IsActive || !reflect.DeepEqual(u.Settings, want) {
+			t.Errorf("SetUserSettings returned %+v", *u)
+		}
+
+		stored, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		if stored.Version != 2 {
+			t.Errorf("GetTeam after SetUserSettings: Version = %d, want 2", stored.Version)
+		}
+		for _, m := range stored.Members {
+			if m.ID == "u1" && !reflect.DeepEqual(m.Settings, want) {
+				t.Errorf("GetTeam: member u1 Settings = %+v, want %+v", m.Settings, want)
+			}
+		}
+
+		if _, err := r.SetUserSettings(ctx, "nope", want); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("SetUserSettings missing: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func testPullRequests(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2", "u3"))
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, got, openPR("pr-1", "u1", "u2", "u3"))
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1"))
+
+		err := r.CreatePullRequest(ctx, openPR("pr-1", "u2"))
+		if !errors.Is(err, repo.ErrPRExists) {
+			t.Fatalf("CreatePullRequest duplicate: err = %v, want ErrPRExists", err)
+		}
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		if got.AuthorID != "u1" {
+			t.Errorf("duplicate create overwrote PR: AuthorID = %q", got.AuthorID)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo(t)
+		if _, err := r.GetPullRequestByID(ctx, "nope"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetPullRequestByID missing: err = %v, want ErrNotFound", err)
+		}
+		if err := r.UpdatePullRequest(ctx, openPR("nope", "u1")); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("UpdatePullRequest missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2"))
+
+		pr, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		at := mergedAt
+		pr.Status = model.PRStatusMerged
+		pr.MergedAt = &at
+		pr.AssignedReviewers = []string{"u3"}
+		if err := r.UpdatePullRequest(ctx, pr); err != nil {
+			t.Fatalf("UpdatePullRequest: %v", err)
+		}
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		want := openPR("pr-1", "u1", "u3")
+		want.Status = model.PRStatusMerged
+		want.MergedAt = &at
+		assertPR(t, got, want)
+	})
+
+	t.Run("ChangedPaths", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		pr := openPR("pr-1", "u1", "u2")
+		pr.ChangedPaths = []string{"api/handler.go", "docs/README.md"}
+		mustCreatePR(t, r, pr)
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, got, pr)
+
+		got.ChangedPaths = []string{"db/schema.sql"}
+		if err := r.UpdatePullRequest(ctx, got); err != nil {
+			t.Fatalf("UpdatePullRequest: %v", err)
+		}
+		again, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, again, got)
+	})
+
+	t.Run("ExcludedReviewers", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		pr := openPR("pr-1", "u1", "u2")
+		pr.ExcludedReviewers = []string{"u3"}
+		mustCreatePR(t, r, pr)
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, got, pr)
+
+		got.ExcludedReviewers = nil
+		if err := r.UpdatePullRequest(ctx, got); err != nil {
+			t.Fatalf("UpdatePullRequest: %v", err)
+		}
+		again, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, again, got)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-2", "u2", "u1"))
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2", "u3"))
+		mustCreatePR(t, r, openPR("pr-3", "u3"))
+
+		prs, err := r.ListPullRequests(ctx)
+		if err != nil {
+			t.Fatalf("ListPullRequests: %v", err)
+		}
+		var got []string
+		for _, pr := range prs {
+			got = append(got, pr.ID)
+		}
+		if fmt.Sprint(got) != "[pr-1 pr-2 pr-3]" {
+			t.Fatalf("ListPullRequests = %v, want [pr-1 pr-2 pr-3]", got)
+		}
+		assertPR(t, &prs[0], openPR("pr-1", "u1", "u2", "u3"))
+	})
+
+	t.Run("ByStatus", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2"))
+		mustCreatePR(t, r, openPR("pr-2", "u2", "u1"))
+		mustCreatePR(t, r, openPR("pr-3", "u3"))
+
+		pr, err := r.GetPullRequestByID(ctx, "pr-2")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		at := mergedAt
+		pr.Status = model.PRStatusMerged
+		pr.MergedAt = &at
+		if err := r.UpdatePullRequest(ctx, pr); err != nil {
+			t.Fatalf("UpdatePullRequest: %v", err)
+		}
+
+		cases := map[model.PRStatus]string{
+			model.PRStatusOpen:   "[pr-1 pr-3]",
+			model.PRStatusMerged: "[pr-2]",
+			"closed":             "[]",
+		}
+		for status, want := range cases {
+			prs, err := r.GetPullRequestsByStatus(ctx, status)
+			if err != nil {
+				t.Fatalf("GetPullRequestsByStatus(%q): %v", status, err)
+			}
+			if got := fmt.Sprint(prIDs(prs)); got != want {
+				t.Errorf("GetPullRequestsByStatus(%q) = %s, want %s", status, got, want)
+			}
+		}
+
+		merged, err := r.GetPullRequestsByStatus(ctx, model.PRStatusMerged)
+		if err != nil {
+			t.Fatalf("GetPullRequestsByStatus: %v", err)
+		}
+		if len(merged) == 1 {
+			want := openPR("pr-2", "u2", "u1")
+			want.Status = model.PRStatusMerged
+			want.MergedAt = &at
+			assertPR(t, &merged[0], want)
+		}
+	})
+}
+
+func testReviewers(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2"))
+
+		reviewers, err := r.GetReviewers(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetReviewers: %v", err)
+		}
+		if fmt.Sprint(reviewers) != "[u2]" {
+			t.Errorf("GetReviewers = %v, want [u2]", reviewers)
+		}
+
+		if err := r.SetReviewers(ctx, "pr-1", []string{"u3", "u2"}); err != nil {
+			t.Fatalf("SetReviewers: %v", err)
+		}
+		reviewers, err = r.GetReviewers(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetReviewers: %v", err)
+		}
+		if fmt.Sprint(reviewers) != "[u3 u2]" {
+			t.Errorf("GetReviewers = %v, want [u3 u2]", reviewers)
+		}
+
+		pr, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		if fmt.Sprint(pr.AssignedReviewers) != "[u3 u2]" {
+			t.Errorf("AssignedReviewers = %v, want [u3 u2]", pr.AssignedReviewers)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1"))
+
+		reviewers, err := r.GetReviewers(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetReviewers: %v", err)
+		}
+		if len(reviewers) != 0 {
+			t.Errorf("GetReviewers = %v, want none", reviewers)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.SetReviewers(ctx, "nope", []string{"u1"}); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("SetReviewers missing: err = %v, want ErrNotFound", err)
+		}
+		if _, err := r.GetReviewers(ctx, "nope"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetReviewers missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ByReviewer", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2", "u3"))
+		mustCreatePR(t, r, openPR("pr-2", "u1", "u3"))
+		mustCreatePR(t, r, openPR("pr-3", "u2", "u1"))
+
+		cases := map[string]string{
+			"u1":    "[pr-3]",
+			"u2":    "[pr-1]",
+			"u3":    "[pr-1 pr-2]",
+			"ghost": "[]",
+		}
+		for user, want := range cases {
+			prs, err := r.GetPullRequestsByReviewer(ctx, user)
+			if err != nil {
+				t.Fatalf("GetPullRequestsByReviewer(%q): %v", user, err)
+			}
+			if got := fmt.Sprint(prIDs(prs)); got != want {
+				t.Errorf("GetPullRequestsByReviewer(%q) = %s, want %s", user, got, want)
+			}
+		}
+
+		prs, err := r.GetPullRequestsByReviewer(ctx, "u2")
+		if err != nil {
+			t.Fatalf("GetPullRequestsByReviewer: %v", err)
+		}
+		if len(prs) == 1 {
+			assertPR(t, &prs[0], openPR("pr-1", "u1", "u2", "u3"))
+		}
+
+		// после переназначения выборка должна обновиться
+		if err := r.SetReviewers(ctx, "pr-1", []string{"u1"}); err != nil {
+			t.Fatalf("SetReviewers: %v", err)
+		}
+		prs, err = r.GetPullRequestsByReviewer(ctx, "u2")
+		if err != nil {
+			t.Fatalf("GetPullRequestsByReviewer: %v", err)
+		}
+		if len(prs) != 0 {
+			t.Errorf("GetPullRequestsByReviewer(u2) after SetReviewers = %v, want none", prIDs(prs))
+		}
+	})
+
+	t.Run("CountOpenReviews", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2", "u3"))
+		mustCreatePR(t, r, openPR("pr-2", "u1", "u2"))
+		mustCreatePR(t, r, openPR("pr-3", "u2", "u1"))
+
+		merged := openPR("pr-4", "u1", "u2", "u3")
+		merged.Status = model.PRStatusMerged
+		at := mergedAt
+		merged.MergedAt = &at
+		mustCreatePR(t, r, merged)
+
+		counts, err := r.CountOpenReviews(ctx, []string{"u1", "u2", "u3", "ghost"})
+		if err != nil {
+			t.Fatalf("CountOpenReviews: %v", err)
+		}
+		want := map[string]int{"u1": 1, "u2": 2, "u3": 1}
+		if !reflect.DeepEqual(counts, want) {
+			t.Errorf("CountOpenReviews = %v, want %v", counts, want)
+		}
+
+		if counts, err := r.CountOpenReviews(ctx, nil); err != nil || len(counts) != 0 {
+			t.Errorf("CountOpenReviews(nil) = %v, %v; want empty", counts, err)
+		}
+	})
+}
+
+func testCopyOnRead(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("TeamInput", func(t *testing.T) {
+		r := newRepo(t)
+		team := backend()
+		mustCreateTeam(t, r, team)
+
+		team.Members[0].Username = "mutated"
+		team.Members = append(team.Members, model.User{ID: "u9"})
+
+		got, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		if len(got.Members) != 3 {
+			t.Errorf("len(Members) = %d, want 3", len(got.Members))
+		}
+		for _, m := range got.Members {
+			if m.Username == "mutated" {
+				t.Error("stored team shares members with CreateTeam argument")
+			}
+		}
+	})
+
+	t.Run("TeamOutput", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		got, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		got.Members[0].IsActive = !got.Members[0].IsActive
+		got.Members[0].Username = "mutated"
+
+		again, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		for _, m := range again.Members {
+			if m.Username == "mutated" {
+				t.Error("GetTeam result shares members with stored team")
+			}
+		}
+	})
+
+	t.Run("UserOutput", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		u, err := r.GetUserByID(ctx, "u1")
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		u.IsActive = false
+
+		again, err := r.GetUserByID(ctx, "u1")
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if !again.IsActive {
+			t.Error("GetUserByID result shares state with stored user")
+		}
+	})
+
+	t.Run("PullRequestInput", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		pr := openPR("pr-1", "u1", "u2", "u3")
+		mustCreatePR(t, r, pr)
+
+		pr.Name = "mutated"
+		pr.AssignedReviewers[0] = "mutated"
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, got, openPR("pr-1", "u1", "u2", "u3"))
+
+		upd := openPR("pr-1", "u1", "u2")
+		upd.Version = got.Version
+		if err := r.UpdatePullRequest(ctx, upd); err != nil {
+			t.Fatalf("UpdatePullRequest: %v", err)
+		}
+		upd.AssignedReviewers[0] = "mutated"
+
+		reviewers := []string{"u3"}
+		if err := r.SetReviewers(ctx, "pr-1", reviewers); err != nil {
+			t.Fatalf("SetReviewers: %v", err)
+		}
+		reviewers[0] = "mutated"
+
+		stored, err := r.GetReviewers(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetReviewers: %v", err)
+		}
+		if fmt.Sprint(stored) != "[u3]" {
+			t.Errorf("GetReviewers = %v, want [u3]", stored)
+		}
+	})
+
+	t.Run("PullRequestOutput", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		pr := openPR("pr-1", "u1", "u2", "u3")
+		pr.ChangedPaths = []string{"main.go"}
+		mustCreatePR(t, r, pr)
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		got.Status = model.PRStatusMerged
+		got.AssignedReviewers[0] = "mutated"
+		got.ChangedPaths[0] = "mutated"
+
+		reviewers, err := r.GetReviewers(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetReviewers: %v", err)
+		}
+		reviewers[1] = "mutated"
+
+		byReviewer, err := r.GetPullRequestsByReviewer(ctx, "u2")
+		if err != nil {
+			t.Fatalf("GetPullRequestsByReviewer: %v", err)
+		}
+		for i := range byReviewer {
+			byReviewer[i].AssignedReviewers[0] = "mutated"
+		}
+
+		again, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		want := openPR("pr-1", "u1", "u2", "u3")
+		want.ChangedPaths = []string{"main.go"}
+		assertPR(t, again, want)
+	})
+}
+
+func testConcurrency(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	const workers = 16
+
+	t.Run("DistinctWrites", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*4)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				id := fmt.Sprintf("pr-%02d", i)
+				if err := r.CreatePullRequest(ctx, openPR(id, "u1", "u2")); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := r.SetUserActive(ctx, "u3", i%2 == 0); err != nil {
+					errs <- err
+				}
+				if err := r.SetReviewers(ctx, id, []string{"u2", "u3"}); err != nil {
+					errs <- err
+				}
+				if _, err := r.GetPullRequestsByReviewer(ctx, "u3"); err != nil {
+					errs <- err
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("concurrent op: %v", err)
+		}
+
+		prs, err := r.GetPullRequestsByReviewer(ctx, "u3")
+		if err != nil {
+			t.Fatalf("GetPullRequestsByReviewer: %v", err)
+		}
+		if len(prs) != workers {
+			t.Errorf("GetPullRequestsByReviewer(u3) returned %d PRs, want %d", len(prs), workers)
+		}
+	})
+
+	t.Run("SameKey", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		var (
+			wg                  sync.WaitGroup
+			mu                  sync.Mutex
+			created, duplicates int
+			teamsCreated        int
+		)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				prErr := r.CreatePullRequest(ctx, openPR("pr-1", fmt.Sprintf("author-%d", i)))
+				teamErr := r.CreateTeam(ctx, &model.Team{Name: "shared"})
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case prErr == nil:
+					created++
+				case errors.Is(prErr, repo.ErrPRExists):
+					duplicates++
+				default:
+					t.Errorf("CreatePullRequest: %v", prErr)
+				}
+				switch {
+				case teamErr == nil:
+					teamsCreated++
+				case !errors.Is(teamErr, repo.ErrTeamExists):
+					t.Errorf("CreateTeam: %v", teamErr)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if created != 1 || duplicates != workers-1 {
+			t.Errorf("CreatePullRequest: %d created, %d ErrPRExists; want 1 and %d", created, duplicates, workers-1)
+		}
+		if teamsCreated != 1 {
+			t.Errorf("CreateTeam: %d created, want 1", teamsCreated)
+		}
+	})
+}
+
+var errAbort = errors.New("abort")
+
+func testTransactions(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("Commit", func(t *testing.T) {
+		r := newRepo(t)
+		err := r.WithTx(ctx, func(tx repo.Repository) error {
+			if err := tx.CreateTeam(ctx, backend()); err != nil {
+				return err
+			}
+			if err := tx.CreatePullRequest(ctx, openPR("pr-1", "u1", "u2")); err != nil {
+				return err
+			}
+			// внутри транзакции видны её же изменения
+			pr, err := tx.GetPullRequestByID(ctx, "pr-1")
+			if err != nil {
+				return err
+			}
+			pr.AssignedReviewers = append(pr.AssignedReviewers, "u3")
+			return tx.UpdatePullRequest(ctx, pr)
+		})
+		if err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, got, openPR("pr-1", "u1", "u2", "u3"))
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2"))
+
+		// транзакция для согласованного чтения видит всё, что закоммичено до неё
+		err := r.WithTx(repo.WithSnapshot(ctx), func(tx repo.Repository) error {
+			teams, err := tx.ListTeams(ctx)
+			if err != nil {
+				return err
+			}
+			if len(teams) != 1 {
+				t.Errorf("ListTeams = %d teams, want 1", len(teams))
+			}
+			prs, err := tx.ListPullRequests(ctx)
+			if err != nil {
+				return err
+			}
+			if len(prs) != 1 {
+				t.Fatalf("ListPullRequests = %d PRs, want 1", len(prs))
+			}
+			assertPR(t, &prs[0], openPR("pr-1", "u1", "u2"))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2"))
+
+		err := r.WithTx(ctx, func(tx repo.Repository) error {
+			if err := tx.CreateTeam(ctx, &model.Team{
+				Name:    "frontend",
+				Members: []model.User{{ID: "u1", Username: "Alice", IsActive: true}, {ID: "u9", Username: "Zed"}},
+			}); err != nil {
+				return err
+			}
+			if _, err := tx.SetUserActive(ctx, "u2", false); err != nil {
+				return err
+			}
+			if err := tx.CreatePullRequest(ctx, openPR("pr-2", "u1", "u2")); err != nil {
+				return err
+			}
+			if err := tx.SetReviewers(ctx, "pr-1", []string{"u3"}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithTx: err = %v, want errAbort", err)
+		}
+
+		if _, err := r.GetTeam(ctx, "frontend"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetTeam(frontend) after rollback: err = %v, want ErrNotFound", err)
+		}
+		if _, err := r.GetUserByID(ctx, "u9"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetUserByID(u9) after rollback: err = %v, want ErrNotFound", err)
+		}
+		if u, err := r.GetUserByID(ctx, "u1"); err != nil || u.TeamName != "backend" {
+			t.Errorf("GetUserByID(u1) after rollback = %+v, %v; want team backend", u, err)
+		}
+		if u, err := r.GetUserByID(ctx, "u2"); err != nil || !u.IsActive {
+			t.Errorf("GetUserByID(u2) after rollback = %+v, %v; want active", u, err)
+		}
+		if _, err := r.GetPullRequestByID(ctx, "pr-2"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetPullRequestByID(pr-2) after rollback: err = %v, want ErrNotFound", err)
+		}
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, got, openPR("pr-1", "u1", "u2"))
+
+		// индексы тоже должны откатиться
+		if prs, err := r.GetPullRequestsByReviewer(ctx, "u3"); err != nil || len(prs) != 0 {
+			t.Errorf("GetPullRequestsByReviewer(u3) after rollback = %v, %v; want none", prIDs(prs), err)
+		}
+		if prs, err := r.GetPullRequestsByReviewer(ctx, "u2"); err != nil || fmt.Sprint(prIDs(prs)) != "[pr-1]" {
+			t.Errorf("GetPullRequestsByReviewer(u2) after rollback = %v, %v; want [pr-1]", prIDs(prs), err)
+		}
+		if prs, err := r.GetPullRequestsByStatus(ctx, model.PRStatusOpen); err != nil || fmt.Sprint(prIDs(prs)) != "[pr-1]" {
+			t.Errorf("GetPullRequestsByStatus(open) after rollback = %v, %v; want [pr-1]", prIDs(prs), err)
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		r := newRepo(t)
+		err := r.WithTx(ctx, func(tx repo.Repository) error {
+			if err := tx.CreateTeam(ctx, backend()); err != nil {
+				return err
+			}
+			return tx.WithTx(ctx, func(inner repo.Repository) error {
+				return inner.CreatePullRequest(ctx, openPR("pr-1", "u1"))
+			})
+		})
+		if err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+		if _, err := r.GetPullRequestByID(ctx, "pr-1"); err != nil {
+			t.Errorf("GetPullRequestByID after nested commit: %v", err)
+		}
+	})
+
+	t.Run("NoLostUpdates", func(t *testing.T) {
+		const workers = 16
+
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1"))
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := r.WithTx(ctx, func(tx repo.Repository) error {
+					pr, err := tx.GetPullRequestByID(ctx, "pr-1")
+					if err != nil {
+						return err
+					}
+					pr.AssignedReviewers = append(pr.AssignedReviewers, fmt.Sprintf("r%02d", i))
+					return tx.UpdatePullRequest(ctx, pr)
+				})
+				if err != nil {
+					t.Errorf("WithTx: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		reviewers, err := r.GetReviewers(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetReviewers: %v", err)
+		}
+		if len(reviewers) != workers {
+			t.Errorf("got %d reviewers after %d concurrent read-modify-write transactions", len(reviewers), workers)
+		}
+	})
+
+	t.Run("NoOverbooking", func(t *testing.T) {
+		const workers = 8
+
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		// каждый назначает u2, только если у него ещё нет открытых ревью
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := r.WithTx(ctx, func(tx repo.Repository) error {
+					load, err := tx.CountOpenReviews(ctx, []string{"u2", "u3"})
+					if err != nil {
+						return err
+					}
+					time.Sleep(5 * time.Millisecond)
+					if load["u2"] > 0 {
+						return nil
+					}
+					return tx.CreatePullRequest(ctx, openPR(fmt.Sprintf("pr-%d", i), "u1", "u2"))
+				})
+				if err != nil {
+					t.Errorf("WithTx: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		load, err := r.CountOpenReviews(ctx, []string{"u2"})
+		if err != nil {
+			t.Fatalf("CountOpenReviews: %v", err)
+		}
+		if load["u2"] != 1 {
+			t.Errorf("u2 has %d open reviews after %d concurrent capacity checks, want 1", load["u2"], workers)
+		}
+	})
+}
+
+func testVersions(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("PullRequest", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		pr := openPR("pr-1", "u1", "u2")
+		mustCreatePR(t, r, pr)
+		if pr.Version != 1 {
+			t.Errorf("CreatePullRequest: pr.Version = %d, want 1", pr.Version)
+		}
+
+		stale, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		fresh, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		if fresh.Version != 1 {
+			t.Errorf("GetPullRequestByID: Version = %d, want 1", fresh.Version)
+		}
+
+		fresh.Name = "renamed"
+		if err := r.UpdatePullRequest(ctx, fresh); err != nil {
+			t.Fatalf("UpdatePullRequest: %v", err)
+		}
+		if fresh.Version != 2 {
+			t.Errorf("UpdatePullRequest: pr.Version = %d, want 2", fresh.Version)
+		}
+
+		stale.Status = model.PRStatusMerged
+		if err := r.UpdatePullRequest(ctx, stale); !errors.Is(err, repo.ErrVersionConflict) {
+			t.Fatalf("UpdatePullRequest with stale version: err = %v, want ErrVersionConflict", err)
+		}
+
+		if err := r.SetReviewers(ctx, "pr-1", []string{"u3"}); err != nil {
+			t.Fatalf("SetReviewers: %v", err)
+		}
+		got, err := r.GetPullRequestByID(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		if got.Version != 3 || got.Name != "renamed" || got.Status != model.PRStatusOpen {
+			t.Errorf("after SetReviewers: Version = %d, Name = %q, Status = %s; want 3, renamed, open",
+				got.Version, got.Name, got.Status)
+		}
+	})
+
+	t.Run("Team", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		team, err := r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		if team.Version != 1 {
+			t.Errorf("GetTeam: Version = %d, want 1", team.Version)
+		}
+
+		if _, err := r.SetUserActive(ctx, "u3", true); err != nil {
+			t.Fatalf("SetUserActive: %v", err)
+		}
+		team, err = r.GetTeam(ctx, "backend")
+		if err != nil {
+			t.Fatalf("GetTeam: %v", err)
+		}
+		if team.Version != 2 {
+			t.Errorf("GetTeam after SetUserActive: Version = %d, want 2", team.Version)
+		}
+	})
+}
+
+func assertPR(t *testing.T, got, want *model.PullRequest) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Name != want.Name || got.AuthorID != want.AuthorID || got.Status != want.Status {
+		t.Errorf("PR = {%s %q %s %s}, want {%s %q %s %s}",
+			got.ID, got.Name, got.AuthorID, got.Status, want.ID, want.Name, want.AuthorID, want.Status)
+	}
+	if fmt.Sprint(got.AssignedReviewers) != fmt.Sprint(want.AssignedReviewers) {
+		t.Errorf("PR %s AssignedReviewers = %v, want %v", want.ID, got.AssignedReviewers, want.AssignedReviewers)
+	}
+	if fmt.Sprint(got.ChangedPaths) != fmt.Sprint(want.ChangedPaths) {
+		t.Errorf("PR %s ChangedPaths = %v, want %v", want.ID, got.ChangedPaths, want.ChangedPaths)
+	}
+	if fmt.Sprint(got.ExcludedReviewers) != fmt.Sprint(want.ExcludedReviewers) {
+		t.Errorf("PR %s ExcludedReviewers = %v, want %v", want.ID, got.ExcludedReviewers, want.ExcludedReviewers)
+	}
+	if got.ShadowReviewer != want.ShadowReviewer {
+		t.Errorf("PR %s ShadowReviewer = %q, want %q", want.ID, got.ShadowReviewer, want.ShadowReviewer)
+	}
+	if !sameTime(got.CreatedAt, want.CreatedAt) {
+		t.Errorf("PR %s CreatedAt = %v, want %v", want.ID, got.CreatedAt, want.CreatedAt)
+	}
+	if !sameTime(got.MergedAt, want.MergedAt) {
+		t.Errorf("PR %s MergedAt = %v, want %v", want.ID, got.MergedAt, want.MergedAt)
+	}
+}
+
+func testContext(t *testing.T, newRepo Factory) {
+	t.Run("Canceled", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		mustCreatePR(t, r, openPR("pr-1", "u1", "u2"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := r.CreateTeam(ctx, &model.Team{Name: "frontend"}); !errors.Is(err, context.Canceled) {
+			t.Errorf("CreateTeam: err = %v, want context.Canceled", err)
+		}
+		if _, err := r.GetPullRequestByID(ctx, "pr-1"); !errors.Is(err, context.Canceled) {
+			t.Errorf("GetPullRequestByID: err = %v, want context.Canceled", err)
+		}
+		if err := r.SetReviewers(ctx, "pr-1", []string{"u3"}); !errors.Is(err, context.Canceled) {
+			t.Errorf("SetReviewers: err = %v, want context.Canceled", err)
+		}
+		if err := r.WithTx(ctx, func(tx repo.Repository) error { return nil }); !errors.Is(err, context.Canceled) {
+			t.Errorf("WithTx: err = %v, want context.Canceled", err)
+		}
+
+		if _, err := r.GetTeam(context.Background(), "frontend"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetTeam(frontend) after canceled create: err = %v, want ErrNotFound", err)
+		}
+		got, err := r.GetPullRequestByID(context.Background(), "pr-1")
+		if err != nil {
+			t.Fatalf("GetPullRequestByID: %v", err)
+		}
+		assertPR(t, got, openPR("pr-1", "u1", "u2"))
+	})
+
+	t.Run("CanceledInsideTx", func(t *testing.T) {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := r.WithTx(ctx, func(tx repo.Repository) error {
+			if err := tx.CreatePullRequest(ctx, openPR("pr-1", "u1", "u2")); err != nil {
+				return err
+			}
+			cancel()
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("WithTx: err = %v, want context.Canceled", err)
+		}
+		if _, err := r.GetPullRequestByID(context.Background(), "pr-1"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("GetPullRequestByID after canceled tx: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sortedUsers(users []model.User) []model.User {
+	out := append([]model.User(nil), users...)
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func userIDs(users []model.User) []string {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func prIDs(prs []model.PullRequest) []string {
+	ids := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		ids = append(ids, pr.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func testPairings(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	day := func(n int) time.Time { return createdAt.AddDate(0, 0, n) }
+	pairings := []model.Pairing{
+		{AuthorID: "u1", ReviewerID: "u2", PullRequestID: "pr-1", AssignedAt: day(0)},
+		{AuthorID: "u1", ReviewerID: "u3", PullRequestID: "pr-1", AssignedAt: day(0)},
+		{AuthorID: "u1", ReviewerID: "u2", PullRequestID: "pr-2", AssignedAt: day(5)},
+		{AuthorID: "u2", ReviewerID: "u1", PullRequestID: "pr-3", AssignedAt: day(3)},
+	}
+
+	t.Run("Count", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.AddPairings(ctx, pairings); err != nil {
+			t.Fatalf("AddPairings: %v", err)
+		}
+
+		got, err := r.CountPairings(ctx, "u1", []string{"u2", "u3", "u4"}, day(0))
+		if err != nil {
+			t.Fatalf("CountPairings: %v", err)
+		}
+		if want := map[string]int{"u2": 2, "u3": 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("CountPairings since day 0 = %v, want %v", got, want)
+		}
+
+		got, err = r.CountPairings(ctx, "u1", []string{"u2", "u3"}, day(1))
+		if err != nil {
+			t.Fatalf("CountPairings: %v", err)
+		}
+		if want := map[string]int{"u2": 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("CountPairings since day 1 = %v, want %v", got, want)
+		}
+
+		got, err = r.CountPairings(ctx, "u1", nil, day(0))
+		if err != nil {
+			t.Fatalf("CountPairings(nil): %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("CountPairings(nil) = %v, want empty", got)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.AddPairings(ctx, pairings[2:]); err != nil {
+			t.Fatalf("AddPairings: %v", err)
+		}
+		if err := r.AddPairings(ctx, pairings[:2]); err != nil {
+			t.Fatalf("AddPairings: %v", err)
+		}
+
+		got, err := r.ListPairings(ctx)
+		if err != nil {
+			t.Fatalf("ListPairings: %v", err)
+		}
+		want := []model.Pairing{pairings[0], pairings[1], pairings[3], pairings[2]}
+		if len(got) != len(want) {
+			t.Fatalf("ListPairings = %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i].AuthorID != want[i].AuthorID || got[i].ReviewerID != want[i].ReviewerID ||
+				got[i].PullRequestID != want[i].PullRequestID || !got[i].AssignedAt.Equal(want[i].AssignedAt) {
+				t.Errorf("ListPairings[%d] = %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.AddPairings(ctx, pairings[:1]); err != nil {
+			t.Fatalf("AddPairings: %v", err)
+		}
+
+		errBoom := errors.New("boom")
+		err := r.WithTx(ctx, func(tx repo.Repository) error {
+			if err := tx.AddPairings(ctx, pairings[1:]); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("WithTx: err = %v, want errBoom", err)
+		}
+
+		got, err := r.ListPairings(ctx)
+		if err != nil {
+			t.Fatalf("ListPairings: %v", err)
+		}
+		if len(got) != 1 || got[0].ReviewerID != "u2" {
+			t.Errorf("ListPairings after rollback = %+v, want only the first pairing", got)
+		}
+	})
+}
+
+func testAssignmentLog(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	records := []model.AssignmentRecord{
+		{
+			PullRequestID: "pr-1",
+			Action:        model.ActionCreate,
+			Assigned:      []string{"u2"},
+			Steps: []model.SelectionStep{{
+				Purpose:  "team",
+				Team:     "backend",
+				Strategy: "random",
+				Count:    1,
+				Pool:     []string{"u1", "u2", "u3"},
+				Excluded: []model.Exclusion{{UserID: "u1", Reason: "author"}, {UserID: "u3", Reason: "inactive"}},
+				Chosen:   []string{"u2"},
+			}},
+			CreatedAt: createdAt,
+		},
+		{
+			PullRequestID:  "pr-1",
+			Action:         model.ActionReassign,
+			ReplacedUserID: "u2",
+			Assigned:       []string{"u4"},
+			CreatedAt:      createdAt.Add(time.Hour),
+		},
+		{PullRequestID: "pr-2", Action: model.ActionCreate, CreatedAt: createdAt},
+	}
+
+	t.Run("AddAndList", func(t *testing.T) {
+		r := newRepo(t)
+		for _, rec := range records {
+			if err := r.AddAssignmentRecord(ctx, rec); err != nil {
+				t.Fatalf("AddAssignmentRecord: %v", err)
+			}
+		}
+
+		got, err := r.ListAssignmentRecords(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("ListAssignmentRecords: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("ListAssignmentRecords = %+v, want 2 records", got)
+		}
+		for i, want := range records[:2] {
+			if !got[i].CreatedAt.Equal(want.CreatedAt) {
+				t.Errorf("record %d CreatedAt = %v, want %v", i, got[i].CreatedAt, want.CreatedAt)
+			}
+			got[i].CreatedAt = want.CreatedAt
+			if !reflect.DeepEqual(got[i], want) {
+				t.Errorf("record %d = %+v, want %+v", i, got[i], want)
+			}
+		}
+
+		got, err = r.ListAssignmentRecords(ctx, "pr-missing")
+		if err != nil {
+			t.Fatalf("ListAssignmentRecords(missing): %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("ListAssignmentRecords(missing) = %+v, want empty", got)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.AddAssignmentRecord(ctx, records[0]); err != nil {
+			t.Fatalf("AddAssignmentRecord: %v", err)
+		}
+
+		errBoom := errors.New("boom")
+		err := r.WithTx(ctx, func(tx repo.Repository) error {
+			if err := tx.AddAssignmentRecord(ctx, records[1]); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("WithTx: err = %v, want errBoom", err)
+		}
+
+		got, err := r.ListAssignmentRecords(ctx, "pr-1")
+		if err != nil {
+			t.Fatalf("ListAssignmentRecords: %v", err)
+		}
+		if len(got) != 1 || got[0].Action != model.ActionCreate {
+			t.Errorf("ListAssignmentRecords after rollback = %+v, want only the first record", got)
+		}
+	})
+}
+
+func testAbsences(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	day := func(n int) time.Time { return createdAt.AddDate(0, 0, n) }
+	vacation := model.Absence{ID: "a1", UserID: "u1", Start: day(1), End: day(8), Reason: "vacation"}
+	sick := model.Absence{ID: "a2", UserID: "u2", Start: day(0), End: day(2)}
+	later := model.Absence{ID: "a3", UserID: "u1", Start: day(20), End: day(21)}
+
+	setup := func(t *testing.T) repo.Repository {
+		r := newRepo(t)
+		mustCreateTeam(t, r, backend())
+		for _, a := range []model.Absence{later, vacation, sick} {
+			if err := r.AddAbsence(ctx, &a); err != nil {
+				t.Fatalf("AddAbsence(%s): %v", a.ID, err)
+			}
+		}
+		return r
+	}
+
+	t.Run("AddAndList", func(t *testing.T) {
+		r := setup(t)
+
+		got, err := r.ListAbsences(ctx, "u1")
+		if err != nil {
+			t.Fatalf("ListAbsences: %v", err)
+		}
+		if len(got) != 2 || got[0].ID != "a1" || got[1].ID != "a3" {
+			t.Fatalf("ListAbsences(u1) = %+v, want a1, a3", got)
+		}
+		if !got[0].Start.Equal(vacation.Start) || !got[0].End.Equal(vacation.End) || got[0].Reason != "vacation" {
+			t.Errorf("ListAbsences(u1)[0] = %+v, want %+v", got[0], vacation)
+		}
+
+		got, err = r.ListAbsences(ctx, "u3")
+		if err != nil {
+			t.Fatalf("ListAbsences(u3): %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("ListAbsences(u3) = %+v, want empty", got)
+		}
+	})
+
+	t.Run("AddErrors", func(t *testing.T) {
+		r := setup(t)
+
+		dup := vacation
+		if err := r.AddAbsence(ctx, &dup); !errors.Is(err, repo.ErrAbsenceExists) {
+			t.Errorf("AddAbsence(duplicate id): err = %v, want ErrAbsenceExists", err)
+		}
+		missing := model.Absence{ID: "a9", UserID: "nobody", Start: day(0), End: day(1)}
+		if err := r.AddAbsence(ctx, &missing); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("AddAbsence(unknown user): err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("AbsentAt", func(t *testing.T) {
+		r := setup(t)
+
+		for _, tc := range []struct {
+			at   time.Time
+			want map[string]bool
+		}{
+			{day(0), map[string]bool{"u2": true}},
+			{day(1), map[string]bool{"u1": true, "u2": true}},
+			{day(2), map[string]bool{"u1": true}},
+			{day(8), map[string]bool{}},
+		} {
+			got, err := r.AbsentAt(ctx, []string{"u1", "u2", "u3"}, tc.at)
+			if err != nil {
+				t.Fatalf("AbsentAt: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("AbsentAt(%v) = %v, want %v", tc.at, got, tc.want)
+			}
+		}
+
+		got, err := r.AbsentAt(ctx, []string{"u3"}, day(1))
+		if err != nil {
+			t.Fatalf("AbsentAt(u3): %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("AbsentAt(u3) = %v, want empty", got)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		r := setup(t)
+
+		deleted, err := r.DeleteAbsence(ctx, "a1")
+		if err != nil {
+			t.Fatalf("DeleteAbsence: %v", err)
+		}
+		if deleted.ID != "a1" || deleted.UserID != "u1" {
+			t.Errorf("DeleteAbsence = %+v, want a1 of u1", deleted)
+		}
+		if _, err := r.DeleteAbsence(ctx, "a1"); !errors.Is(err, repo.ErrNotFound) {
+			t.Errorf("DeleteAbsence again: err = %v, want ErrNotFound", err)
+		}
+
+		got, err := r.AbsentAt(ctx, []string{"u1"}, day(2))
+		if err != nil {
+			t.Fatalf("AbsentAt: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("AbsentAt after delete = %v, want empty", got)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		r := setup(t)
+
+		errBoom := errors.New("boom")
+		err := r.WithTx(ctx, func(tx repo.Repository) error {
+			if _, err := tx.DeleteAbsence(ctx, "a1"); err != nil {
+				return err
+			}
+			extra := model.Absence{ID: "a4", UserID: "u3", Start: day(0), End: day(1)}
+			if err := tx.AddAbsence(ctx, &extra); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("WithTx: err = %v, want errBoom", err)
+		}
+
+		got, err := r.AbsentAt(ctx, []string{"u1", "u3"}, day(1))
+		if err != nil {
+			t.Fatalf("AbsentAt: %v", err)
+		}
+		if want := map[string]bool{"u1": true}; !reflect.DeepEqual(got, want) {
+			t.Errorf("AbsentAt after rollback = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		r := setup(t)
+
+		// sick заканчивается ровно в day(2): end не входит в период
+		n, err := r.PruneAbsences(ctx, day(2))
+		if err != nil {
+			t.Fatalf("PruneAbsences: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("PruneAbsences(day 2) = %d, want 1", n)
+		}
+		if n, err = r.PruneAbsences(ctx, day(2)); err != nil || n != 0 {
+			t.Errorf("PruneAbsences again = %d, %v, want 0", n, err)
+		}
+
+		got, err := r.ListAbsences(ctx, "u2")
+		if err != nil {
+			t.Fatalf("ListAbsences(u2): %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("ListAbsences(u2) after prune = %+v, want empty", got)
+		}
+		if got, err = r.ListAbsences(ctx, "u1"); err != nil || len(got) != 2 {
+			t.Errorf("ListAbsences(u1) after prune = %+v, %v, want a1, a3", got, err)
+		}
+
+		errBoom := errors.New("boom")
+		err = r.WithTx(ctx, func(tx repo.Repository) error {
+			if n, err := tx.PruneAbsences(ctx, day(10)); err != nil || n != 1 {
+				t.Errorf("PruneAbsences(day 10) in tx = %d, %v, want 1", n, err)
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("WithTx: err = %v, want errBoom", err)
+		}
+		absent, err := r.AbsentAt(ctx, []string{"u1", "u2"}, day(1))
+		if err != nil {
+			t.Fatalf("AbsentAt: %v", err)
+		}
+		if want := map[string]bool{"u1": true}; !reflect.DeepEqual(absent, want) {
+			t.Errorf("AbsentAt after rolled back prune = %v, want %v", absent, want)
+		}
+	})
+}
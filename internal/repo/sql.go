@@ -0,0 +1,976 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+// sqlRepo — общая реализация Repository поверх database/sql.
+// Запросы написаны так, чтобы работать и в PostgreSQL, и в SQLite.
+type sqlRepo struct {
+	db      *sql.DB
+	dialect string
+
+	q  querier // s.db или s.tx
+	tx *sql.Tx // не nil внутри WithTx
+	// snapshot — транзакция открыта по WithSnapshot и только читает.
+	snapshot bool
+}
+
+// querier — общее подмножество *sql.DB и *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func newSQLRepo(db *sql.DB, dialect string) sqlRepo {
+	return sqlRepo{db: db, dialect: dialect, q: db}
+}
+
+func (s *sqlRepo) Close() error {
+	return s.db.Close()
+}
+
+// WithTx выполняет fn в одной транзакции БД. Вложенный вызов использует
+// уже открытую транзакцию.
+func (s *sqlRepo) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	var opts *sql.TxOptions
+	snapshot := isSnapshot(ctx)
+	if snapshot && s.dialect == dialectPostgres {
+		opts = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+
+	// при отмене ctx database/sql сам откатит транзакцию
+	tx, err := s.db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txRepo := &sqlRepo{db: s.db, dialect: s.dialect, q: tx, tx: tx, snapshot: snapshot}
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// atomic выполняет несколько запросов одного метода атомарно: в текущей
+// транзакции, если она есть, иначе в собственной.
+func (s *sqlRepo) atomic(ctx context.Context, fn func(q querier) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// forUpdate блокирует прочитанную строку до конца транзакции, чтобы
+// read-modify-write внутри WithTx не терял чужие изменения. В SQLite
+// единственное соединение и так сериализует транзакции. Транзакции
+// по WithSnapshot ничего не пишут, им блокировки не нужны.
+func (s *sqlRepo) forUpdate() string {
+	if s.tx != nil && !s.snapshot && s.dialect == dialectPostgres {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+func (s *sqlRepo) CreateTeam(ctx context.Context, team *model.Team) error {
+	settings, err := encodeSettings(team.Settings)
+	if err != nil {
+		return err
+	}
+
+	return s.atomic(ctx, func(q querier) error {
+		res, err := q.ExecContext(ctx, `INSERT INTO teams (name, settings) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING`, team.Name, settings)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrTeamExists
+		}
+
+		for _, u := range team.Members {
+			userSettings, err := encodeSettings(u.Settings)
+			if err != nil {
+				return err
+			}
+			_, err = q.ExecContext(ctx, `
+				INSERT INTO users (id, username, team_name, is_active, settings)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (id) DO UPDATE
+				SET username = EXCLUDED.username,
+				    team_name = EXCLUDED.team_name,
+				    is_active = EXCLUDED.is_active,
+				    settings = EXCLUDED.settings`,
+				u.ID, u.Username, team.Name, u.IsActive, userSettings)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *sqlRepo) GetTeam(ctx context.Context, name string) (*model.Team, error) {
+	var (
+		version  int64
+		settings string
+	)
+	err := s.q.QueryRowContext(ctx, `SELECT version, settings FROM teams WHERE name = $1`, name).Scan(&version, &settings)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	teamSettings, err := decodeSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE team_name = $1
+		ORDER BY id`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	team := &model.Team{Name: name, Members: []model.User{}, Settings: teamSettings, Version: version}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		team.Members = append(team.Members, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+func (s *sqlRepo) ListTeams(ctx context.Context) ([]model.Team, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT name, version, settings FROM teams ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []model.Team
+	byName := make(map[string]int)
+	for rows.Next() {
+		var settings string
+		team := model.Team{Members: []model.User{}}
+		if err := rows.Scan(&team.Name, &team.Version, &settings); err != nil {
+			return nil, err
+		}
+		if team.Settings, err = decodeSettings(settings); err != nil {
+			return nil, err
+		}
+		byName[team.Name] = len(teams)
+		teams = append(teams, team)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	users, err := s.q.QueryContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer users.Close()
+
+	for users.Next() {
+		u, err := scanUser(users)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := byName[u.TeamName]; ok {
+			teams[i].Members = append(teams[i].Members, *u)
+		}
+	}
+	if err := users.Err(); err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+func (s *sqlRepo) SetTeamSettings(ctx context.Context, name string, settings model.TeamSettings) (*model.Team, error) {
+	encoded, err := encodeSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.q.ExecContext(ctx, `UPDATE teams SET settings = $2, version = version + 1 WHERE name = $1`, name, encoded)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.GetTeam(ctx, name)
+}
+
+func (s *sqlRepo) GetRotationCursor(ctx context.Context, teamName string) (string, error) {
+	// внутри транзакции строка команды блокируется до записи нового курсора,
+	// чтобы параллельные назначения не выбрали одного и того же
+	var cursor string
+	err := s.q.QueryRowContext(ctx, `SELECT rotation_cursor FROM teams WHERE name = $1`+s.forUpdate(), teamName).Scan(&cursor)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return cursor, nil
+}
+
+func (s *sqlRepo) SetRotationCursor(ctx context.Context, teamName, userID string) error {
+	res, err := s.q.ExecContext(ctx, `UPDATE teams SET rotation_cursor = $2 WHERE name = $1`, teamName, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *sqlRepo) SetUserActive(ctx context.Context, userID string, isActive bool) (*model.User, error) {
+	return s.updateUser(ctx, `UPDATE users SET is_active = $2 WHERE id = $1 RETURNING `+userColumns, userID, isActive)
+}
+
+func (s *sqlRepo) SetUserSettings(ctx context.Context, userID string, settings model.UserSettings) (*model.User, error) {
+	encoded, err := encodeSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, `UPDATE users SET settings = $2 WHERE id = $1 RETURNING `+userColumns, userID, encoded)
+}
+
+// updateUser выполняет UPDATE ... RETURNING пользователя и увеличивает версию его команды.
+func (s *sqlRepo) updateUser(ctx context.Context, query string, args ...any) (*model.User, error) {
+	var u *model.User
+	err := s.atomic(ctx, func(q querier) error {
+		var err error
+		u, err = scanUser(q.QueryRowContext(ctx, query, args...))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, `UPDATE teams SET version = version + 1 WHERE name = $1`, u.TeamName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *sqlRepo) GetUserByID(ctx context.Context, userID string) (*model.User, error) {
+	u, err := scanUser(s.q.QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE id = $1`,
+		userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+const userColumns = "id, username, team_name, is_active, settings"
+
+// scanUser читает пользователя из строки с колонками userColumns.
+func scanUser(row interface{ Scan(dest ...any) error }) (*model.User, error) {
+	var (
+		u        model.User
+		settings string
+	)
+	if err := row.Scan(&u.ID, &u.Username, &u.TeamName, &u.IsActive, &settings); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(settings), &u.Settings); err != nil {
+		return nil, fmt.Errorf("decode user settings: %w", err)
+	}
+	return &u, nil
+}
+
+func (s *sqlRepo) CreatePullRequest(ctx context.Context, pr *model.PullRequest) error {
+	paths, err := encodeList(pr.ChangedPaths)
+	if err != nil {
+		return err
+	}
+	excluded, err := encodeList(pr.ExcludedReviewers)
+	if err != nil {
+		return err
+	}
+
+	return s.atomic(ctx, func(q querier) error {
+		res, err := q.ExecContext(ctx, `
+			INSERT INTO pull_requests (id, name, author_id, status, created_at, merged_at, changed_paths, excluded_reviewers, shadow_reviewer)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (id) DO NOTHING`,
+			pr.ID, pr.Name, pr.AuthorID, string(pr.Status), nullTime(pr.CreatedAt), nullTime(pr.MergedAt), paths, excluded, pr.ShadowReviewer)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrPRExists
+		}
+
+		if err := replaceReviewers(ctx, q, pr.ID, pr.AssignedReviewers); err != nil {
+			return err
+		}
+		pr.Version = 1
+		return nil
+	})
+}
+
+func (s *sqlRepo) GetPullRequestByID(ctx context.Context, id string) (*model.PullRequest, error) {
+	var (
+		pr        model.PullRequest
+		status    string
+		createdAt sql.NullTime
+		mergedAt  sql.NullTime
+		paths     string
+		excluded  string
+	)
+	err := s.q.QueryRowContext(ctx, `
+		SELECT id, name, author_id, status, created_at, merged_at, changed_paths, excluded_reviewers, shadow_reviewer, version
+		FROM pull_requests
+		WHERE id = $1`+s.forUpdate(),
+		id).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &status, &createdAt, &mergedAt, &paths, &excluded, &pr.ShadowReviewer, &pr.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	pr.Status = model.PRStatus(status)
+	pr.CreatedAt = timePtr(createdAt)
+	pr.MergedAt = timePtr(mergedAt)
+	if pr.ChangedPaths, err = decodeList(paths, "changed paths"); err != nil {
+		return nil, err
+	}
+	if pr.ExcludedReviewers, err = decodeList(excluded, "excluded reviewers"); err != nil {
+		return nil, err
+	}
+
+	reviewers, err := s.GetReviewers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pr.AssignedReviewers = reviewers
+
+	return &pr, nil
+}
+
+func (s *sqlRepo) UpdatePullRequest(ctx context.Context, pr *model.PullRequest) error {
+	paths, err := encodeList(pr.ChangedPaths)
+	if err != nil {
+		return err
+	}
+	excluded, err := encodeList(pr.ExcludedReviewers)
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	err = s.atomic(ctx, func(q querier) error {
+		err := q.QueryRowContext(ctx, `
+			UPDATE pull_requests
+			SET name = $2, author_id = $3, status = $4, created_at = $5, merged_at = $6,
+			    changed_paths = $7, excluded_reviewers = $8, shadow_reviewer = $9, version = version + 1
+			WHERE id = $1 AND version = $10
+			RETURNING version`,
+			pr.ID, pr.Name, pr.AuthorID, string(pr.Status), nullTime(pr.CreatedAt), nullTime(pr.MergedAt), paths, excluded, pr.ShadowReviewer, pr.Version,
+		).Scan(&version)
+		if errors.Is(err, sql.ErrNoRows) {
+			// либо PR нет, либо версия устарела
+			var exists bool
+			if err := q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pull_requests WHERE id = $1)`, pr.ID).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return ErrNotFound
+			}
+			return ErrVersionConflict
+		}
+		if err != nil {
+			return err
+		}
+
+		return replaceReviewers(ctx, q, pr.ID, pr.AssignedReviewers)
+	})
+	if err != nil {
+		return err
+	}
+
+	pr.Version = version
+	return nil
+}
+
+func (s *sqlRepo) SetReviewers(ctx context.Context, prID string, reviewers []string) error {
+	return s.atomic(ctx, func(q querier) error {
+		res, err := q.ExecContext(ctx, `UPDATE pull_requests SET version = version + 1 WHERE id = $1`, prID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrNotFound
+		}
+
+		return replaceReviewers(ctx, q, prID, reviewers)
+	})
+}
+
+func (s *sqlRepo) GetReviewers(ctx context.Context, prID string) ([]string, error) {
+	var exists bool
+	err := s.q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pull_requests WHERE id = $1)`, prID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT user_id
+		FROM pr_reviewers
+		WHERE pr_id = $1
+		ORDER BY position`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviewers := make([]string, 0)
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviewers, nil
+}
+
+func (s *sqlRepo) GetPullRequestsByReviewer(ctx context.Context, userID string) ([]model.PullRequest, error) {
+	return s.pullRequestsByIDs(ctx, `
+		SELECT DISTINCT pr_id
+		FROM pr_reviewers
+		WHERE user_id = $1
+		ORDER BY pr_id`, userID)
+}
+
+// lockUsers блокирует строки пользователей в порядке id: так два запроса
+// с пересекающимися наборами не захватывают их навстречу друг другу.
+func (s *sqlRepo) lockUsers(ctx context.Context, userIDs []string, lock string) error {
+	args := make([]any, 0, len(userIDs))
+	placeholders := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id
+		FROM users
+		WHERE id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY id`+lock, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+func (s *sqlRepo) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	args := []any{string(model.PRStatusOpen)}
+	placeholders := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+	in := strings.Join(placeholders, ", ")
+
+	// внутри транзакции строки пользователей блокируются до её конца: иначе
+	// два параллельных назначения увидят одно и то же число открытых ревью
+	// и оба назначат пользователя сверх лимита
+	if lock := s.forUpdate(); lock != "" {
+		if err := s.lockUsers(ctx, userIDs, lock); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT r.user_id, COUNT(DISTINCT r.pr_id)
+		FROM pr_reviewers r
+		JOIN pull_requests pr ON pr.id = r.pr_id
+		WHERE pr.status = $1 AND r.user_id IN (`+in+`)
+		GROUP BY r.user_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id string
+			n  int
+		)
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (s *sqlRepo) AddPairings(ctx context.Context, pairings []model.Pairing) error {
+	return s.atomic(ctx, func(q querier) error {
+		for _, p := range pairings {
+			_, err := q.ExecContext(ctx, `
+				INSERT INTO pairings (author_id, reviewer_id, pr_id, assigned_at)
+				VALUES ($1, $2, $3, $4)`,
+				p.AuthorID, p.ReviewerID, p.PullRequestID, p.AssignedAt.UTC())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqlRepo) CountPairings(ctx context.Context, authorID string, reviewerIDs []string, since time.Time) (map[string]int, error) {
+	counts := make(map[string]int, len(reviewerIDs))
+	if len(reviewerIDs) == 0 {
+		return counts, nil
+	}
+
+	args := []any{authorID, since.UTC()}
+	placeholders := make([]string, 0, len(reviewerIDs))
+	for _, id := range reviewerIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT reviewer_id, COUNT(*)
+		FROM pairings
+		WHERE author_id = $1 AND assigned_at >= $2 AND reviewer_id IN (`+strings.Join(placeholders, ", ")+`)
+		GROUP BY reviewer_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id string
+			n  int
+		)
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (s *sqlRepo) ListPairings(ctx context.Context) ([]model.Pairing, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT author_id, reviewer_id, pr_id, assigned_at
+		FROM pairings
+		ORDER BY assigned_at, author_id, pr_id, reviewer_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairings []model.Pairing
+	for rows.Next() {
+		var p model.Pairing
+		if err := rows.Scan(&p.AuthorID, &p.ReviewerID, &p.PullRequestID, &p.AssignedAt); err != nil {
+			return nil, err
+		}
+		p.AssignedAt = p.AssignedAt.UTC()
+		pairings = append(pairings, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pairings, nil
+}
+
+func (s *sqlRepo) AddAssignmentRecord(ctx context.Context, rec model.AssignmentRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	return s.atomic(ctx, func(q querier) error {
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO assignment_log (pr_id, position, created_at, record)
+			SELECT $1, COALESCE(MAX(position), -1) + 1, $2, $3
+			FROM assignment_log
+			WHERE pr_id = $1`,
+			rec.PullRequestID, rec.CreatedAt.UTC(), string(data))
+		return err
+	})
+}
+
+func (s *sqlRepo) ListAssignmentRecords(ctx context.Context, prID string) ([]model.AssignmentRecord, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT record
+		FROM assignment_log
+		WHERE pr_id = $1
+		ORDER BY position`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []model.AssignmentRecord
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var rec model.AssignmentRecord
+		if err := json.Unmarshal([]byte(data), &rec); err != nil {
+			return nil, fmt.Errorf("decode assignment record: %w", err)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (s *sqlRepo) AddAbsence(ctx context.Context, absence *model.Absence) error {
+	return s.atomic(ctx, func(q querier) error {
+		var exists bool
+		err := q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, absence.UserID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+
+		res, err := q.ExecContext(ctx, `
+			INSERT INTO absences (id, user_id, starts_at, ends_at, reason)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (id) DO NOTHING`,
+			absence.ID, absence.UserID, absence.Start.UTC(), absence.End.UTC(), absence.Reason)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrAbsenceExists
+		}
+		return nil
+	})
+}
+
+func (s *sqlRepo) DeleteAbsence(ctx context.Context, id string) (*model.Absence, error) {
+	var deleted *model.Absence
+
+	err := s.atomic(ctx, func(q querier) error {
+		a, err := scanAbsence(q.QueryRowContext(ctx, `
+			SELECT id, user_id, starts_at, ends_at, reason
+			FROM absences
+			WHERE id = $1`, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.ExecContext(ctx, `DELETE FROM absences WHERE id = $1`, id); err != nil {
+			return err
+		}
+		deleted = a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+func (s *sqlRepo) PruneAbsences(ctx context.Context, before time.Time) (int, error) {
+	res, err := s.q.ExecContext(ctx, `DELETE FROM absences WHERE ends_at <= $1`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (s *sqlRepo) ListAbsences(ctx context.Context, userID string) ([]model.Absence, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT id, user_id, starts_at, ends_at, reason
+		FROM absences
+		WHERE user_id = $1
+		ORDER BY starts_at, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var absences []model.Absence
+	for rows.Next() {
+		a, err := scanAbsence(rows)
+		if err != nil {
+			return nil, err
+		}
+		absences = append(absences, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return absences, nil
+}
+
+func (s *sqlRepo) AbsentAt(ctx context.Context, userIDs []string, at time.Time) (map[string]bool, error) {
+	absent := make(map[string]bool)
+	if len(userIDs) == 0 {
+		return absent, nil
+	}
+
+	args := []any{at.UTC()}
+	placeholders := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT DISTINCT user_id
+		FROM absences
+		WHERE starts_at <= $1 AND ends_at > $1 AND user_id IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		absent[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return absent, nil
+}
+
+func scanAbsence(row interface{ Scan(dest ...any) error }) (*model.Absence, error) {
+	var a model.Absence
+	if err := row.Scan(&a.ID, &a.UserID, &a.Start, &a.End, &a.Reason); err != nil {
+		return nil, err
+	}
+	a.Start, a.End = a.Start.UTC(), a.End.UTC()
+	return &a, nil
+}
+
+func (s *sqlRepo) GetPullRequestsByStatus(ctx context.Context, status model.PRStatus) ([]model.PullRequest, error) {
+	return s.pullRequestsByIDs(ctx, `
+		SELECT id
+		FROM pull_requests
+		WHERE status = $1
+		ORDER BY id`, string(status))
+}
+
+func (s *sqlRepo) ListPullRequests(ctx context.Context) ([]model.PullRequest, error) {
+	return s.pullRequestsByIDs(ctx, `SELECT id FROM pull_requests ORDER BY id`)
+}
+
+// pullRequestsByIDs загружает PR, id которых вернул query.
+func (s *sqlRepo) pullRequestsByIDs(ctx context.Context, query string, args ...any) ([]model.PullRequest, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []model.PullRequest
+	for _, id := range ids {
+		pr, err := s.GetPullRequestByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *pr)
+	}
+
+	return result, nil
+}
+
+func replaceReviewers(ctx context.Context, q querier, prID string, reviewers []string) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE pr_id = $1`, prID); err != nil {
+		return err
+	}
+	for i, r := range reviewers {
+		if _, err := q.ExecContext(ctx, `INSERT INTO pr_reviewers (pr_id, position, user_id) VALUES ($1, $2, $3)`, prID, i, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Настройки команд и пользователей хранятся JSON-строкой в колонке settings.
+func encodeSettings(settings any) (string, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeSettings(data string) (model.TeamSettings, error) {
+	var settings model.TeamSettings
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		return settings, fmt.Errorf("decode team settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Списки PR (пути, исключённые ревьюверы) хранятся JSON-массивами
+// в столбцах pull_requests.
+func encodeList(items []string) (string, error) {
+	if items == nil {
+		items = []string{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeList(data, column string) ([]string, error) {
+	var items []string
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", column, err)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return items, nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time.UTC()
+	return &v
+}
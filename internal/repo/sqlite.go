@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+type SQLiteRepo struct {
+	sqlRepo
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{newSQLRepo(db, dialectSQLite)}
+}
+
+// OpenSQLite открывает (или создаёт) файл базы по пути path. Схему создают миграции (см. MigrateUp).
+func OpenSQLite(path string) (*SQLiteRepo, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite допускает только одного писателя; одно соединение избавляет от SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return NewSQLiteRepo(db), nil
+}
+
+func sqliteDSN(path string) string {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	return "file:" + path + "?" + q.Encode()
+}
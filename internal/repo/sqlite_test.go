@@ -0,0 +1,26 @@
+package repo_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo/repotest"
+)
+
+func TestSQLiteRepo(t *testing.T) {
+	repotest.Run(t, newSQLiteRepo)
+}
+
+func newSQLiteRepo(tb testing.TB) repo.Repository {
+	r, err := repo.OpenSQLite(filepath.Join(tb.TempDir(), "repo.db"))
+	if err != nil {
+		tb.Fatalf("OpenSQLite: %v", err)
+	}
+	tb.Cleanup(func() { r.Close() })
+
+	if _, err := r.MigrateUp(); err != nil {
+		tb.Fatalf("MigrateUp: %v", err)
+	}
+	return r
+}
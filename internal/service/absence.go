@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+var (
+	// ErrInvalidAbsence — период отсутствия пустой или уже закончился.
+	ErrInvalidAbsence = errors.New("invalid absence")
+	// ErrAbsenceExists — сгенерированный id отсутствия уже занят.
+	ErrAbsenceExists = errors.New("absence already exists")
+)
+
+// ListAbsences возвращает отсутствия пользователя. Закончившиеся удаляются
+// при регистрации новых, поэтому прошедшие могут уже не попасть в список.
+func (s *Service) ListAbsences(ctx context.Context, userID string) ([]model.Absence, error) {
+	var absences []model.Absence
+
+	err := s.repo.WithTx(ctx, func(tx repo.Repository) error {
+		if _, err := tx.GetUserByID(ctx, userID); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		var err error
+		absences, err = tx.ListAbsences(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return absences, nil
+}
+
+// AddAbsence регистрирует отсутствие пользователя с start до end (end не
+// входит). Пока оно идёт, пользователь не назначается ревьювером, но
+// остаётся активным. Заодно удаляются все уже закончившиеся отсутствия.
+func (s *Service) AddAbsence(ctx context.Context, userID string, start, end time.Time, reason string) (*model.Absence, error) {
+	now := s.now()
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end must be after start", ErrInvalidAbsence)
+	}
+	if !end.After(now) {
+		return nil, fmt.Errorf("%w: absence is already over", ErrInvalidAbsence)
+	}
+
+	id, err := newAbsenceID()
+	if err != nil {
+		return nil, err
+	}
+	absence := &model.Absence{
+		ID:     id,
+		UserID: userID,
+		Start:  start.UTC(),
+		End:    end.UTC(),
+		Reason: reason,
+	}
+	err = s.repo.WithTx(ctx, func(tx repo.Repository) error {
+		if err := tx.AddAbsence(ctx, absence); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			if errors.Is(err, repo.ErrAbsenceExists) {
+				return ErrAbsenceExists
+			}
+			return err
+		}
+		_, err := tx.PruneAbsences(ctx, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return absence, nil
+}
+
+// newAbsenceID возвращает случайный id отсутствия. Он берётся из crypto/rand,
+// а не из источника сервиса: иначе регистрация отсутствия сдвигала бы
+// последовательность выбора ревьюверов, а при одинаковом seed id повторялись бы.
+func newAbsenceID() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("absence id: %w", err)
+	}
+	return "abs-" + hex.EncodeToString(b[:]), nil
+}
+
+// CancelAbsence удаляет отсутствие и возвращает его.
+func (s *Service) CancelAbsence(ctx context.Context, id string) (*model.Absence, error) {
+	absence, err := s.repo.DeleteAbsence(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return absence, nil
+}
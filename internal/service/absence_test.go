@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+func TestAbsenceIDs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	settings := model.TeamSettings{ReviewerCount: 1, ReviewerStrategy: service.StrategyRandom}
+	ids := []string{"author", "u1", "u2", "u3", "u4", "u5"}
+	opts := func() []service.Option {
+		return []service.Option{
+			service.WithRand(rand.NewSource(7)),
+			service.WithClock(func() time.Time { return now }),
+		}
+	}
+	plain := newTeam(t, settings, ids, opts()...)
+	absent := newTeam(t, settings, ids, opts()...)
+
+	// одинаковый seed не даёт одинаковых id
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		a, err := absent.AddAbsence(ctx, "author", now.Add(-time.Hour), now.Add(time.Hour), "")
+		if err != nil {
+			t.Fatalf("AddAbsence: %v", err)
+		}
+		if !strings.HasPrefix(a.ID, "abs-") || seen[a.ID] {
+			t.Errorf("absence id %q is malformed or repeated", a.ID)
+		}
+		seen[a.ID] = true
+	}
+
+	// и регистрация отсутствий не сдвигает выбор ревьюверов
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("pr-%d", i)
+		want, err := plain.CreatePullRequest(ctx, id, "change", "author", service.CreatePROptions{})
+		if err != nil {
+			t.Fatalf("CreatePullRequest: %v", err)
+		}
+		got, err := absent.CreatePullRequest(ctx, id, "change", "author", service.CreatePROptions{})
+		if err != nil {
+			t.Fatalf("CreatePullRequest: %v", err)
+		}
+		if !slices.Equal(got.PR.AssignedReviewers, want.PR.AssignedReviewers) {
+			t.Errorf("%s: assigned %v after absences, want %v", id, got.PR.AssignedReviewers, want.PR.AssignedReviewers)
+		}
+	}
+}
+
+func TestAbsencePruned(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	s := newTeam(t, model.TeamSettings{}, []string{"author", "u1", "u2"},
+		service.WithClock(func() time.Time { return now }))
+
+	old, err := s.AddAbsence(ctx, "u1", now, now.Add(24*time.Hour), "sick")
+	if err != nil {
+		t.Fatalf("AddAbsence: %v", err)
+	}
+
+	// через два дня первое отсутствие закончилось и удаляется при добавлении нового
+	now = now.Add(48 * time.Hour)
+	if _, err := s.AddAbsence(ctx, "u2", now, now.Add(24*time.Hour), "vacation"); err != nil {
+		t.Fatalf("AddAbsence: %v", err)
+	}
+	absences, err := s.ListAbsences(ctx, "u1")
+	if err != nil {
+		t.Fatalf("ListAbsences: %v", err)
+	}
+	if len(absences) != 0 {
+		t.Errorf("ListAbsences(u1) = %+v, want %s pruned", absences, old.ID)
+	}
+	if absences, err = s.ListAbsences(ctx, "u2"); err != nil || len(absences) != 1 {
+		t.Errorf("ListAbsences(u2) = %+v, %v, want the new absence", absences, err)
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/backup"
+)
+
+var (
+	ErrStoreNotEmpty = errors.New("storage is not empty")
+	ErrBadDump       = backup.ErrBadDump
+)
+
+func (s *Service) Export(ctx context.Context) (*backup.Dump, error) {
+	return backup.Export(ctx, s.repo, s.now())
+}
+
+// Import читает дамп (JSON или NDJSON) и загружает его в пустое хранилище.
+// Пропущенные записи перечислены в отчёте.
+func (s *Service) Import(ctx context.Context, r io.Reader) (*backup.Report, error) {
+	d, err := backup.Read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rep, err := backup.Import(ctx, s.repo, d)
+	if err != nil {
+		if errors.Is(err, backup.ErrNotEmpty) {
+			return nil, ErrStoreNotEmpty
+		}
+		return nil, err
+	}
+	return rep, nil
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+func TestCapacity(t *testing.T) {
+	ctx := context.Background()
+	settings := model.TeamSettings{ReviewerCount: 1, MaxOpenReviews: 1}
+	s := newTeam(t, settings, []string{"a", "b", "c"})
+
+	// лимит команды — по одному открытому ревью
+	first := createPR(t, s, "pr-1", "a", service.CreatePROptions{})
+	second := createPR(t, s, "pr-2", "a", service.CreatePROptions{})
+	got := slices.Concat(first, second)
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"b", "c"}) {
+		t.Fatalf("assigned %v and %v, want b and c once each", first, second)
+	}
+
+	// все заняты — PR не создаётся
+	_, err := s.CreatePullRequest(ctx, "pr-3", "change", "a", service.CreatePROptions{})
+	if !errors.Is(err, service.ErrAtCapacity) {
+		t.Fatalf("CreatePullRequest with everyone full: err = %v, want ErrAtCapacity", err)
+	}
+	if _, err := s.GetPullRequest(ctx, "pr-3"); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("pr-3 was stored: err = %v", err)
+	}
+
+	// личный лимит важнее командного
+	if _, err := s.SetUserSettings(ctx, "b", model.UserSettings{MaxOpenReviews: 2}); err != nil {
+		t.Fatalf("SetUserSettings: %v", err)
+	}
+	res, err := s.CreatePullRequest(ctx, "pr-3", "change", "a", service.CreatePROptions{})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if !slices.Equal(res.PR.AssignedReviewers, []string{"b"}) {
+		t.Errorf("assigned %v, want [b] with its own limit of 2", res.PR.AssignedReviewers)
+	}
+	if !slices.Equal(res.AtCapacity, []string{"c"}) {
+		t.Errorf("at capacity %v, want [c]", res.AtCapacity)
+	}
+
+	// после merge место освобождается
+	if _, err := s.MergePullRequest(ctx, "pr-3", 0); err != nil {
+		t.Fatalf("MergePullRequest: %v", err)
+	}
+	if got := createPR(t, s, "pr-4", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("pr-4: assigned %v, want [b]", got)
+	}
+}
+
+func TestCapacityPartial(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, model.TeamSettings{ReviewerCount: 2}, []string{"a", "b", "c", "d"})
+	if _, err := s.SetUserSettings(ctx, "b", model.UserSettings{MaxOpenReviews: 1}); err != nil {
+		t.Fatalf("SetUserSettings: %v", err)
+	}
+	createPR(t, s, "pr-0", "c", service.CreatePROptions{RequiredReviewers: []string{"b"}, ReviewerCount: 1})
+
+	// b занят: назначаются c и d, а ответ сообщает, что b пропущен
+	res, err := s.CreatePullRequest(ctx, "pr-1", "change", "a", service.CreatePROptions{})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if got := res.PR.AssignedReviewers; slices.Contains(got, "b") || len(got) != 2 {
+		t.Errorf("assigned %v, want c and d", got)
+	}
+	if !slices.Equal(res.AtCapacity, []string{"b"}) {
+		t.Errorf("at capacity %v, want [b]", res.AtCapacity)
+	}
+
+	records, err := s.ExplainPullRequest(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("ExplainPullRequest: %v", err)
+	}
+	excluded := records[0].Steps[0].Excluded
+	if !slices.Contains(excluded, model.Exclusion{UserID: "b", Reason: "at_capacity"}) {
+		t.Errorf("explain excluded %v, want b at_capacity", excluded)
+	}
+}
+
+func TestCapacityReassign(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, model.TeamSettings{ReviewerCount: 1, MaxOpenReviews: 1}, []string{"a", "b", "c"})
+
+	first := createPR(t, s, "pr-1", "a", service.CreatePROptions{})
+	createPR(t, s, "pr-2", "a", service.CreatePROptions{})
+
+	// заменить некем: единственный другой кандидат уже занят
+	_, err := s.ReassignReviewer(ctx, "pr-1", first[0], 0)
+	if !errors.Is(err, service.ErrAtCapacity) {
+		t.Errorf("ReassignReviewer: err = %v, want ErrAtCapacity", err)
+	}
+}
@@ -0,0 +1,109 @@
+package service_test
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+// reasons собирает причины исключения шага по пользователям.
+func reasons(step model.SelectionStep) map[string]string {
+	got := make(map[string]string, len(step.Excluded))
+	for _, e := range step.Excluded {
+		got[e.UserID] = e.Reason
+	}
+	return got
+}
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	settings := model.TeamSettings{ReviewerCount: 2, ReviewerStrategy: service.StrategyLeastLoaded}
+	s := newTeam(t, settings, []string{"a", "b", "c", "d", "e", "f", "g"})
+	if _, err := s.SetUserIsActive(ctx, "d", false); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+	if _, err := s.SetUserSettings(ctx, "e", model.UserSettings{MaxOpenReviews: 1}); err != nil {
+		t.Fatalf("SetUserSettings: %v", err)
+	}
+	addLoad(t, s, "e", 1)
+
+	assigned := createPR(t, s, "pr-1", "a", service.CreatePROptions{
+		RequiredReviewers: []string{"b"},
+		ExcludedReviewers: []string{"c"},
+	})
+
+	records, err := s.ExplainPullRequest(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("ExplainPullRequest: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	create := records[0]
+	if create.Action != model.ActionCreate || !slices.Equal(create.Assigned, assigned) {
+		t.Errorf("record = %s %v, want create %v", create.Action, create.Assigned, assigned)
+	}
+	if len(create.Steps) != 2 {
+		t.Fatalf("got %d steps, want required and team", len(create.Steps))
+	}
+
+	required := create.Steps[0]
+	if required.Purpose != "required" || !slices.Equal(required.Chosen, []string{"b"}) {
+		t.Errorf("step 0 = %s chose %v, want required [b]", required.Purpose, required.Chosen)
+	}
+
+	team := create.Steps[1]
+	if team.Purpose != "team" || team.Team != "backend" || team.Strategy != service.StrategyLeastLoaded || team.Count != 1 {
+		t.Errorf("step 1 = %s/%s/%s count %d, want team/backend/%s count 1",
+			team.Purpose, team.Team, team.Strategy, team.Count, service.StrategyLeastLoaded)
+	}
+	pool := slices.Clone(team.Pool)
+	slices.Sort(pool)
+	if want := []string{"a", "b", "c", "d", "e", "f", "g"}; !slices.Equal(pool, want) {
+		t.Errorf("pool %v, want %v", pool, want)
+	}
+	want := map[string]string{
+		"a": "author",
+		"b": "already_assigned",
+		"c": "excluded",
+		"d": "inactive",
+		"e": "at_capacity",
+	}
+	if got := reasons(team); !maps.Equal(got, want) {
+		t.Errorf("excluded %v, want %v", got, want)
+	}
+	if len(team.Chosen) != 1 || !slices.Contains([]string{"f", "g"}, team.Chosen[0]) {
+		t.Errorf("chosen %v, want one of f, g", team.Chosen)
+	}
+
+	res, err := s.ReassignReviewer(ctx, "pr-1", "b", 0)
+	if err != nil {
+		t.Fatalf("ReassignReviewer: %v", err)
+	}
+	records, err = s.ExplainPullRequest(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("ExplainPullRequest: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	reassign := records[1]
+	if reassign.Action != model.ActionReassign || reassign.ReplacedUserID != "b" {
+		t.Errorf("record = %s replacing %q, want reassign replacing b", reassign.Action, reassign.ReplacedUserID)
+	}
+	if len(reassign.Steps) != 1 {
+		t.Fatalf("got %d steps, want 1", len(reassign.Steps))
+	}
+	step := reassign.Steps[0]
+	if step.Purpose != "replacement" || !slices.Equal(step.Chosen, []string{res.Replacement.UserID}) {
+		t.Errorf("step = %s chose %v, want replacement [%s]", step.Purpose, step.Chosen, res.Replacement.UserID)
+	}
+	got := reasons(step)
+	if got["b"] != "replaced" || got[team.Chosen[0]] != "already_assigned" {
+		t.Errorf("excluded %v, want b replaced and %s already_assigned", got, team.Chosen[0])
+	}
+}
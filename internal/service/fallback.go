@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// Assignment — назначенный ревьювер и его команда.
+type Assignment struct {
+	UserID   string
+	TeamName string
+	// CrossTeam — ревьювер не из команды автора PR.
+	CrossTeam bool
+}
+
+// fallbackTeams возвращает запасные команды home в порядке приоритета.
+// Несуществующие команды и команды из skip пропускаются. Запасные команды
+// запасных команд не просматриваются.
+func fallbackTeams(ctx context.Context, tx repo.Repository, home *model.Team, skip ...string) ([]*model.Team, error) {
+	var teams []*model.Team
+	for _, name := range home.Settings.FallbackTeams {
+		if name == home.Name || slices.Contains(skip, name) {
+			continue
+		}
+		team, err := tx.GetTeam(ctx, name)
+		if errors.Is(err, repo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+// chooseFromFallbacks добирает ревьюверов из запасных команд home, пока
+// на PR назначено меньше count. Выбирает стратегия запасной команды.
+func (p *picker) chooseFromFallbacks(ctx context.Context, home *model.Team, pr *model.PullRequest, count int) error {
+	if count-len(pr.AssignedReviewers) <= 0 {
+		return nil
+	}
+
+	teams, err := fallbackTeams(ctx, p.tx, home)
+	if err != nil {
+		return err
+	}
+	for _, team := range teams {
+		rest := count - len(pr.AssignedReviewers)
+		if rest <= 0 {
+			break
+		}
+
+		chosen, err := p.choose(ctx, pr, selection{purpose: purposeFallback, team: team, pool: team.Members, count: rest})
+		if err != nil {
+			return err
+		}
+		for _, c := range chosen {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, c.ID)
+		}
+	}
+	return nil
+}
+
+// authorTeam возвращает команду автора PR; nil, если автора или команды уже нет.
+func authorTeam(ctx context.Context, tx repo.Repository, pr *model.PullRequest) (*model.Team, error) {
+	author, err := tx.GetUserByID(ctx, pr.AuthorID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	team, err := tx.GetTeam(ctx, author.TeamName)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// assignments описывает ревьюверов PR относительно команды автора.
+func assignments(ctx context.Context, tx repo.Repository, pr *model.PullRequest, authorTeam string) ([]Assignment, error) {
+	result := make([]Assignment, 0, len(pr.AssignedReviewers))
+	for _, id := range pr.AssignedReviewers {
+		a, err := assignmentOf(ctx, tx, id, authorTeam)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+func assignmentOf(ctx context.Context, tx repo.Repository, userID, authorTeam string) (Assignment, error) {
+	u, err := tx.GetUserByID(ctx, userID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return Assignment{UserID: userID}, nil
+	}
+	if err != nil {
+		return Assignment{}, err
+	}
+	return Assignment{UserID: u.ID, TeamName: u.TeamName, CrossTeam: u.TeamName != authorTeam}, nil
+}
@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+// newFallbackTeams создаёт команду backend (a, b, c) с запасными ops (o1)
+// и sre (s1, s2); в backend активны только автор a и b.
+func newFallbackTeams(t *testing.T) *service.Service {
+	t.Helper()
+	ctx := context.Background()
+	settings := model.TeamSettings{ReviewerCount: 2, FallbackTeams: []string{"ops", "sre"}}
+	s := newTeam(t, settings, []string{"a", "b", "c"})
+	if _, err := s.CreateTeam(ctx, "ops", users("o1"), model.TeamSettings{}); err != nil {
+		t.Fatalf("CreateTeam(ops): %v", err)
+	}
+	if _, err := s.CreateTeam(ctx, "sre", users("s1", "s2"), model.TeamSettings{}); err != nil {
+		t.Fatalf("CreateTeam(sre): %v", err)
+	}
+	if _, err := s.SetUserIsActive(ctx, "c", false); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+	return s
+}
+
+func TestFallback(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		inactive []string
+		want     []service.Assignment // UserID "" — любой из TeamName
+	}{
+		{
+			"first fallback",
+			nil,
+			[]service.Assignment{
+				{UserID: "b", TeamName: "backend"},
+				{UserID: "o1", TeamName: "ops", CrossTeam: true},
+			},
+		},
+		{
+			"next fallback in order",
+			[]string{"o1"},
+			[]service.Assignment{
+				{UserID: "b", TeamName: "backend"},
+				{TeamName: "sre", CrossTeam: true},
+			},
+		},
+		{
+			"home team empty",
+			[]string{"b", "o1", "s1"},
+			[]service.Assignment{
+				{UserID: "s2", TeamName: "sre", CrossTeam: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFallbackTeams(t)
+			for _, id := range tt.inactive {
+				if _, err := s.SetUserIsActive(ctx, id, false); err != nil {
+					t.Fatalf("SetUserIsActive: %v", err)
+				}
+			}
+
+			res, err := s.CreatePullRequest(ctx, "pr-1", "change", "a", service.CreatePROptions{})
+			if err != nil {
+				t.Fatalf("CreatePullRequest: %v", err)
+			}
+			if !matchAssignments(res.Assignments, tt.want) {
+				t.Errorf("assignments %+v, want %+v", res.Assignments, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackReassign(t *testing.T) {
+	ctx := context.Background()
+	s := newFallbackTeams(t)
+	if _, err := s.SetUserIsActive(ctx, "o1", false); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+	createPR(t, s, "pr-1", "a", service.CreatePROptions{ReviewerCount: 1})
+
+	// в backend замены нет, ops пуст — замена приходит из sre
+	res, err := s.ReassignReviewer(ctx, "pr-1", "b", 0)
+	if err != nil {
+		t.Fatalf("ReassignReviewer: %v", err)
+	}
+	if r := res.Replacement; r.TeamName != "sre" || !r.CrossTeam {
+		t.Errorf("replacement %+v, want a cross-team reviewer from sre", r)
+	}
+}
+
+func matchAssignments(got, want []service.Assignment) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i, w := range want {
+		if w.UserID == "" {
+			w.UserID = got[i].UserID
+		}
+		if got[i] != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// inHours делит кандидатов на тех, кому удобно взять ревью сейчас, и остальных.
+// Удобно, если в момент операции у кандидата рабочее время или его часы
+// пересекаются с часами автора PR. Кандидаты без заданных часов считаются
+// удобными. Некорректные часы отклоняются при записи (API и импорт),
+// поэтому ошибку разбора здесь не проверяем.
+func (p *picker) inHours(ctx context.Context, pr *model.PullRequest, candidates []model.User) (preferred, offHours []model.User, err error) {
+	author, err := p.loadAuthorHours(ctx, pr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range candidates {
+		h, _ := c.Settings.WorkingHours()
+		if h == nil || h.Contains(p.now) || (author != nil && h.Overlaps(author, p.now)) {
+			preferred = append(preferred, c)
+		} else {
+			offHours = append(offHours, c)
+		}
+	}
+	return preferred, offHours, nil
+}
+
+// loadAuthorHours возвращает рабочие часы автора PR; nil — не заданы.
+func (p *picker) loadAuthorHours(ctx context.Context, pr *model.PullRequest) (*model.WorkingHours, error) {
+	if p.authorLoaded {
+		return p.authorHours, nil
+	}
+
+	u, err := p.tx.GetUserByID(ctx, pr.AuthorID)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return nil, err
+	}
+	if u != nil {
+		p.authorHours, _ = u.Settings.WorkingHours()
+	}
+	p.authorLoaded = true
+	return p.authorHours, nil
+}
@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+func TestWorkingHoursPreferred(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	s := newTeam(t, model.TeamSettings{ReviewerCount: 2, ReviewerStrategy: service.StrategyRandom},
+		[]string{"author", "day", "evening", "anytime"}, service.WithClock(func() time.Time { return now }))
+
+	set := func(id, tz, start, end string) {
+		t.Helper()
+		settings := model.UserSettings{Timezone: tz, WorkStart: start, WorkEnd: end}
+		if _, err := s.SetUserSettings(ctx, id, settings); err != nil {
+			t.Fatalf("SetUserSettings(%s): %v", id, err)
+		}
+	}
+	set("day", "Europe/Moscow", "09:00", "18:00")        // в 20:00 UTC — 23:00 по Москве
+	set("evening", "America/New_York", "09:00", "18:00") // 15:00 по Нью-Йорку
+
+	for i := 0; i < 10; i++ {
+		res, err := s.CreatePullRequest(ctx, fmt.Sprintf("pr-%d", i), "change", "author", service.CreatePROptions{})
+		if err != nil {
+			t.Fatalf("CreatePullRequest: %v", err)
+		}
+		got := slices.Clone(res.PR.AssignedReviewers)
+		slices.Sort(got)
+		if want := []string{"anytime", "evening"}; !slices.Equal(got, want) {
+			t.Errorf("assigned %v at %v, want %v", got, now, want)
+		}
+	}
+
+	// утром по Москве всё наоборот: в Нью-Йорке ночь
+	now = time.Date(2026, 3, 3, 7, 0, 0, 0, time.UTC)
+	res, err := s.CreatePullRequest(ctx, "pr-morning", "change", "author", service.CreatePROptions{})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	got := slices.Clone(res.PR.AssignedReviewers)
+	slices.Sort(got)
+	if want := []string{"anytime", "day"}; !slices.Equal(got, want) {
+		t.Errorf("assigned %v at %v, want %v", got, now, want)
+	}
+}
+
+func TestWorkingHoursRejected(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, model.TeamSettings{}, []string{"author", "u1"})
+
+	bad := []model.UserSettings{
+		{Timezone: "Mars/Base"},
+		{WorkStart: "09:00"},
+		{WorkStart: "9am", WorkEnd: "18:00"},
+	}
+	for _, settings := range bad {
+		if _, err := s.SetUserSettings(ctx, "u1", settings); !errors.Is(err, service.ErrInvalidSettings) {
+			t.Errorf("SetUserSettings(%+v) = %v, want ErrInvalidSettings", settings, err)
+		}
+	}
+}
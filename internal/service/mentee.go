@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+)
+
+// isMentee сообщает, числится ли u подопечным своей команды.
+func (p *picker) isMentee(ctx context.Context, u model.User) (bool, error) {
+	team, err := p.team(ctx, u.TeamName)
+	if err != nil || team == nil {
+		return false, err
+	}
+	return slices.Contains(team.Settings.Mentees, u.ID), nil
+}
+
+// chooseShadow добавляет на PR теневого ревьювера — одного из подопечных
+// команды home. Теневой ревьювер не входит в число ревьюверов PR; если
+// подопечных нет или никого из них сейчас назначить нельзя, слот остаётся пустым.
+func (p *picker) chooseShadow(ctx context.Context, home *model.Team, pr *model.PullRequest) error {
+	var pool []model.User
+	for _, u := range home.Members {
+		if slices.Contains(home.Settings.Mentees, u.ID) {
+			pool = append(pool, u)
+		}
+	}
+
+	chosen, err := p.choose(ctx, pr, selection{purpose: purposeShadow, team: home, pool: pool, count: 1, shadow: true})
+	if err != nil {
+		return err
+	}
+	if len(chosen) > 0 {
+		pr.ShadowReviewer = chosen[0].ID
+	}
+	return nil
+}
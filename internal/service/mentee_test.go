@@ -0,0 +1,81 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+func TestShadowReviewer(t *testing.T) {
+	tests := []struct {
+		name     string
+		members  []string
+		mentees  []string
+		inactive string
+		count    int
+		assigned []string // nil — любые два не-подопечных
+		shadow   []string // допустимые теневые ревьюверы; nil — без теневого
+	}{
+		{"mentee shadows", []string{"a", "b", "c", "m"}, []string{"m"}, "", 2, nil, []string{"m"}},
+		{"no mentees", []string{"a", "b", "c", "m"}, nil, "", 2, nil, nil},
+		{"mentees never review", []string{"a", "b", "m", "n"}, []string{"m", "n"}, "", 3, []string{"b"}, []string{"m", "n"}},
+		{"author does not shadow own PR", []string{"a", "b", "c"}, []string{"a"}, "", 2, []string{"b", "c"}, nil},
+		{"other mentee shadows author's PR", []string{"a", "b", "c", "m"}, []string{"a", "m"}, "", 2, []string{"b", "c"}, []string{"m"}},
+		{"inactive mentee", []string{"a", "b", "c", "m"}, []string{"m"}, "m", 2, []string{"b", "c"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(0); seed < 10; seed++ {
+				settings := model.TeamSettings{ReviewerCount: tt.count, Mentees: tt.mentees}
+				s := newTeam(t, settings, tt.members, service.WithRand(rand.NewSource(seed)))
+				if tt.inactive != "" {
+					if _, err := s.SetUserIsActive(context.Background(), tt.inactive, false); err != nil {
+						t.Fatalf("SetUserIsActive: %v", err)
+					}
+				}
+
+				res, err := s.CreatePullRequest(context.Background(), "pr-1", "change", "a", service.CreatePROptions{})
+				if err != nil {
+					t.Fatalf("CreatePullRequest: %v", err)
+				}
+				got := slices.Clone(res.PR.AssignedReviewers)
+				slices.Sort(got)
+				if tt.assigned != nil && !slices.Equal(got, tt.assigned) {
+					t.Errorf("seed %d: assigned %v, want %v", seed, got, tt.assigned)
+				}
+				for _, id := range tt.mentees {
+					if slices.Contains(got, id) {
+						t.Errorf("seed %d: mentee %s assigned as reviewer", seed, id)
+					}
+				}
+
+				shadow := res.PR.ShadowReviewer
+				switch {
+				case tt.shadow == nil && shadow != "":
+					t.Errorf("seed %d: shadow %q, want none", seed, shadow)
+				case tt.shadow != nil && !slices.Contains(tt.shadow, shadow):
+					t.Errorf("seed %d: shadow %q, want one of %v", seed, shadow, tt.shadow)
+				}
+			}
+		})
+	}
+}
+
+func TestMenteeNotReplacement(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, model.TeamSettings{ReviewerCount: 1, Mentees: []string{"m"}}, []string{"a", "b", "m"})
+
+	if got := createPR(t, s, "pr-1", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"b"}) {
+		t.Fatalf("assigned %v, want [b]", got)
+	}
+	_, err := s.ReassignReviewer(ctx, "pr-1", "b", 0)
+	if !errors.Is(err, service.ErrNoCandidate) {
+		t.Errorf("ReassignReviewer: err = %v, want ErrNoCandidate", err)
+	}
+}
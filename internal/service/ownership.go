@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/codeowners"
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// ownerArea — правило CODEOWNERS и затронутые им пути PR.
+type ownerArea struct {
+	rule  *codeowners.Rule
+	paths []string
+	// ownerIDs — все владельцы области, кроме автора PR, owners — те из них,
+	// кто существует.
+	ownerIDs []string
+	owners   []model.User
+}
+
+func (a ownerArea) coveredBy(reviewers []string) bool {
+	for _, r := range reviewers {
+		if slices.Contains(a.ownerIDs, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownerAreas группирует пути PR по правилам CODEOWNERS команды автора
+// в порядке правил. Пути, у которых нет владельцев, не попадают никуда.
+func ownerAreas(ctx context.Context, tx repo.Repository, team *model.Team, pr *model.PullRequest) ([]ownerArea, error) {
+	if len(pr.ChangedPaths) == 0 || team.Settings.CodeOwners == "" {
+		return nil, nil
+	}
+
+	rules, err := codeowners.Parse(team.Settings.CodeOwners)
+	if err != nil {
+		return nil, fmt.Errorf("team %s: %w", team.Name, err)
+	}
+
+	byLine := make(map[int]*ownerArea)
+	var lines []int
+	for _, path := range pr.ChangedPaths {
+		rule := rules.Match(path)
+		if rule == nil || len(rule.Owners) == 0 {
+			continue
+		}
+		area, ok := byLine[rule.Line]
+		if !ok {
+			area = &ownerArea{rule: rule}
+			byLine[rule.Line] = area
+			lines = append(lines, rule.Line)
+		}
+		area.paths = append(area.paths, path)
+	}
+
+	sort.Ints(lines)
+	res := ownerResolver{tx: tx, team: team, users: make(map[string]*model.User)}
+	areas := make([]ownerArea, 0, len(lines))
+	for _, line := range lines {
+		area := byLine[line]
+		if area.ownerIDs, area.owners, err = res.resolve(ctx, area.rule.Owners, pr.AuthorID); err != nil {
+			return nil, err
+		}
+		areas = append(areas, *area)
+	}
+	return areas, nil
+}
+
+// chooseOwners назначает на PR по владельцу на каждую область, которую ещё
+// не покрывает никто из назначенных. Возвращает пути областей, для которых
+// свободного владельца не нашлось. Владельца выбирает стратегия команды,
+// но очередь round-robin при этом не сдвигается (см. selection.rotates).
+func (p *picker) chooseOwners(ctx context.Context, team *model.Team, pr *model.PullRequest, areas []ownerArea) ([]string, error) {
+	var uncovered []string
+	for _, area := range areas {
+		if area.coveredBy(pr.AssignedReviewers) {
+			continue
+		}
+
+		chosen, err := p.choose(ctx, pr, selection{purpose: purposeOwner, team: team, pool: area.owners, count: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(chosen) == 0 {
+			uncovered = append(uncovered, area.paths...)
+			continue
+		}
+		pr.AssignedReviewers = append(pr.AssignedReviewers, chosen[0].ID)
+	}
+	return uncovered, nil
+}
+
+// ownersLosingCoverage возвращает владельцев тех областей, которые покрывал
+// только oldUserID: замену лучше искать среди них.
+func ownersLosingCoverage(areas []ownerArea, pr *model.PullRequest, oldUserID string) []model.User {
+	remaining := make([]string, 0, len(pr.AssignedReviewers))
+	for _, r := range pr.AssignedReviewers {
+		if r != oldUserID {
+			remaining = append(remaining, r)
+		}
+	}
+
+	var owners []model.User
+	seen := make(map[string]bool)
+	for _, area := range areas {
+		if !area.coveredBy([]string{oldUserID}) || area.coveredBy(remaining) {
+			continue
+		}
+		for _, o := range area.owners {
+			if o.ID == oldUserID || seen[o.ID] {
+				continue
+			}
+			seen[o.ID] = true
+			owners = append(owners, o)
+		}
+	}
+	return owners
+}
+
+// reassignOwners возвращает владельцев, среди которых искать замену oldUserID,
+// чтобы PR не потерял покрытие владельцами; пусто — подойдёт любой кандидат.
+// Правила берутся из home — команды автора PR.
+func reassignOwners(ctx context.Context, tx repo.Repository, home *model.Team, pr *model.PullRequest, oldUserID string) ([]model.User, error) {
+	if home == nil || len(pr.ChangedPaths) == 0 {
+		return nil, nil
+	}
+
+	areas, err := ownerAreas(ctx, tx, home, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	var owners []model.User
+	for _, o := range ownersLosingCoverage(areas, pr, oldUserID) {
+		if !slices.Contains(pr.AssignedReviewers, o.ID) {
+			owners = append(owners, o)
+		}
+	}
+	return owners, nil
+}
+
+// ownerResolver раскрывает владельцев @user_id и @team/group в пользователей.
+type ownerResolver struct {
+	tx    repo.Repository
+	team  *model.Team
+	users map[string]*model.User // nil — пользователя нет
+}
+
+// resolve возвращает id всех владельцев, кроме автора, и тех из них,
+// кто существует.
+func (r *ownerResolver) resolve(ctx context.Context, owners []string, authorID string) ([]string, []model.User, error) {
+	var ids []string
+	for _, owner := range owners {
+		user, group, err := codeowners.ParseOwner(owner)
+		if err != nil {
+			return nil, nil, err
+		}
+		if user != "" {
+			ids = append(ids, user)
+			continue
+		}
+		members, err := r.group(ctx, group)
+		if err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, members...)
+	}
+
+	var (
+		all   []string
+		users []model.User
+	)
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] || id == authorID {
+			continue
+		}
+		seen[id] = true
+		all = append(all, id)
+
+		u, err := r.user(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if u != nil {
+			users = append(users, *u)
+		}
+	}
+	return all, users, nil
+}
+
+// group возвращает участников группы "team/group"; неизвестная группа пуста.
+func (r *ownerResolver) group(ctx context.Context, name string) ([]string, error) {
+	teamName, group, _ := strings.Cut(name, "/")
+
+	team := r.team
+	if teamName != team.Name {
+		var err error
+		team, err = r.tx.GetTeam(ctx, teamName)
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return team.Settings.OwnerGroups[group], nil
+}
+
+func (r *ownerResolver) user(ctx context.Context, id string) (*model.User, error) {
+	if u, ok := r.users[id]; ok {
+		return u, nil
+	}
+	u, err := r.tx.GetUserByID(ctx, id)
+	if errors.Is(err, repo.ErrNotFound) {
+		u, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.users[id] = u
+	return u, nil
+}
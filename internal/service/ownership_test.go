@@ -0,0 +1,100 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+func TestOwnersBeforeTeamPool(t *testing.T) {
+	ctx := context.Background()
+	settings := model.TeamSettings{
+		ReviewerCount: 3,
+		CodeOwners:    "/api/ @o1\n*.md @backend/docs\n/generated/",
+		OwnerGroups:   map[string][]string{"docs": {"d1", "d2"}},
+	}
+	ids := []string{"author", "o1", "d1", "d2", "u1", "u2", "u3", "u4"}
+
+	// с любым сидом владельцы назначаются первыми, остальные — из пула команды
+	for seed := int64(0); seed < 20; seed++ {
+		s := newTeam(t, settings, ids, service.WithRand(rand.NewSource(seed)))
+		prID := fmt.Sprintf("pr-%d", seed)
+		res, err := s.CreatePullRequest(ctx, prID, "change", "author", service.CreatePROptions{
+			ChangedPaths: []string{"api/handler.go", "docs/guide.md", "generated/x.go"},
+		})
+		if err != nil {
+			t.Fatalf("seed %d: CreatePullRequest: %v", seed, err)
+		}
+
+		got := res.PR.AssignedReviewers
+		if len(got) != 3 {
+			t.Fatalf("seed %d: assigned %v, want 3 reviewers", seed, got)
+		}
+		if got[0] != "o1" {
+			t.Errorf("seed %d: first reviewer %s, want api owner o1", seed, got[0])
+		}
+		if !slices.Contains([]string{"d1", "d2"}, got[1]) {
+			t.Errorf("seed %d: second reviewer %s, want a docs owner", seed, got[1])
+		}
+		if len(res.UncoveredPaths) != 0 {
+			t.Errorf("seed %d: uncovered %v", seed, res.UncoveredPaths)
+		}
+
+		records, err := s.ExplainPullRequest(ctx, prID)
+		if err != nil {
+			t.Fatalf("seed %d: ExplainPullRequest: %v", seed, err)
+		}
+		var purposes []string
+		for _, step := range records[0].Steps {
+			purposes = append(purposes, step.Purpose)
+		}
+		if want := []string{"owner", "owner", "team"}; !slices.Equal(purposes, want) {
+			t.Errorf("seed %d: steps %v, want %v", seed, purposes, want)
+		}
+	}
+}
+
+func TestOwnersUnavailable(t *testing.T) {
+	ctx := context.Background()
+	settings := model.TeamSettings{ReviewerCount: 2, CodeOwners: "/api/ @o1"}
+	s := newTeam(t, settings, []string{"author", "o1", "u1", "u2"})
+
+	if _, err := s.SetUserIsActive(ctx, "o1", false); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+	res, err := s.CreatePullRequest(ctx, "pr-1", "change", "author", service.CreatePROptions{
+		ChangedPaths: []string{"api/handler.go"},
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if !slices.Equal(res.UncoveredPaths, []string{"api/handler.go"}) {
+		t.Errorf("uncovered %v, want [api/handler.go]", res.UncoveredPaths)
+	}
+	if len(res.PR.AssignedReviewers) != 2 || slices.Contains(res.PR.AssignedReviewers, "o1") {
+		t.Errorf("assigned %v, want two of the team pool", res.PR.AssignedReviewers)
+	}
+}
+
+func TestOwnersKeepRotation(t *testing.T) {
+	settings := model.TeamSettings{
+		ReviewerCount:    2,
+		ReviewerStrategy: service.StrategyRoundRobin,
+		CodeOwners:       "* @b",
+	}
+	s := newTeam(t, settings, []string{"a", "b", "c", "d", "e", "f"})
+
+	// b назначается владельцем на каждый PR, а второе место идёт по очереди
+	want := []string{"c", "d", "e", "f", "c"}
+	for i, w := range want {
+		got := createPR(t, s, fmt.Sprintf("pr-%d", i), "a", service.CreatePROptions{ChangedPaths: []string{"main.go"}})
+		if !slices.Equal(got, []string{"b", w}) {
+			t.Errorf("PR %d: assigned %v, want [b %s]", i, got, w)
+		}
+	}
+}
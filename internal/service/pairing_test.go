@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+func TestPairingAware(t *testing.T) {
+	ctx := context.Background()
+	today := time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC)
+
+	// b ревьюил a трижды 20 дней назад, c — один раз 2 дня назад
+	history := []struct {
+		reviewer string
+		daysAgo  int
+	}{
+		{"b", 20}, {"b", 20}, {"b", 20}, {"c", 2},
+	}
+
+	tests := []struct {
+		name   string
+		window int
+		want   string
+	}{
+		{"recent pair penalised", 7, "b"},
+		{"older pairs count inside window", 30, "c"},
+		{"default window is 30 days", 0, "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(0); seed < 10; seed++ {
+				now := today
+				settings := model.TeamSettings{
+					ReviewerCount:     1,
+					ReviewerStrategy:  service.StrategyPairingAware,
+					PairingWindowDays: tt.window,
+				}
+				s := newTeam(t, settings, []string{"a", "b", "c"},
+					service.WithRand(rand.NewSource(seed)), service.WithClock(func() time.Time { return now }))
+
+				for i, h := range history {
+					now = today.AddDate(0, 0, -h.daysAgo)
+					id := fmt.Sprintf("old-%d", i)
+					createPR(t, s, id, "a", service.CreatePROptions{RequiredReviewers: []string{h.reviewer}})
+					if _, err := s.MergePullRequest(ctx, id, 0); err != nil {
+						t.Fatalf("MergePullRequest: %v", err)
+					}
+				}
+
+				now = today
+				if got := createPR(t, s, "pr-1", "a", service.CreatePROptions{}); !slices.Equal(got, []string{tt.want}) {
+					t.Fatalf("seed %d: assigned %v, want [%s]", seed, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPairingAwareTieBreak(t *testing.T) {
+	// пар ни у кого нет — выбирается наименее загруженный
+	settings := model.TeamSettings{ReviewerCount: 1, ReviewerStrategy: service.StrategyPairingAware}
+	for seed := int64(0); seed < 10; seed++ {
+		s := newTeam(t, settings, []string{"a", "b", "c", "d"}, service.WithRand(rand.NewSource(seed)))
+		addLoad(t, s, "b", 2)
+		addLoad(t, s, "c", 1)
+		addLoad(t, s, "d", 2)
+
+		if got := createPR(t, s, "pr-1", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"c"}) {
+			t.Fatalf("seed %d: assigned %v, want least loaded [c]", seed, got)
+		}
+	}
+}
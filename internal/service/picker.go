@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// ErrAtCapacity — все подходящие кандидаты уже набрали предельное число открытых ревью.
+var ErrAtCapacity = errors.New("all candidates are at review capacity")
+
+// Зачем выбирался ревьювер — поле purpose в журнале назначений.
+const (
+	purposeRequired    = "required"
+	purposeOwner       = "owner"
+	purposeSenior      = "senior"
+	purposeTeam        = "team"
+	purposeFallback    = "fallback"
+	purposeReplacement = "replacement"
+	purposeShadow      = "shadow"
+)
+
+// Почему кандидат из пула не был назначен.
+const (
+	reasonAuthor          = "author"
+	reasonExcluded        = "excluded"
+	reasonInactive        = "inactive"
+	reasonAlreadyAssigned = "already_assigned"
+	reasonReplaced        = "replaced"
+	reasonNotSenior       = "not_senior"
+	reasonMentee          = "mentee"
+	reasonAbsent          = "absent"
+	reasonAtCapacity      = "at_capacity"
+)
+
+// picker выбирает ревьюверов в рамках одной операции: отсеивает кандидатов,
+// которых сейчас назначать нельзя, передаёт остальных стратегии и запоминает,
+// кого и почему пропустил.
+type picker struct {
+	svc   *Service
+	tx    repo.Repository
+	now   time.Time              // время операции
+	teams map[string]*model.Team // кеш команд кандидатов
+
+	// replaced — ревьювер, которого заменяют при переназначении.
+	replaced string
+	// excluded — кого нельзя назначать: исключения PR и правила автора.
+	excluded []string
+	// authorHours — рабочие часы автора PR, если authorLoaded.
+	authorHours  *model.WorkingHours
+	authorLoaded bool
+
+	// atCapacity — кандидаты, пропущенные из-за лимита открытых ревью.
+	atCapacity []string
+	// steps — все выборы операции для журнала назначений.
+	steps []model.SelectionStep
+}
+
+func (s *Service) newPicker(tx repo.Repository, now time.Time) *picker {
+	return &picker{svc: s, tx: tx, now: now, teams: make(map[string]*model.Team)}
+}
+
+// selection — один выбор: до count ревьюверов из pool стратегией team.
+type selection struct {
+	purpose string
+	team    *model.Team
+	// pool — все, кого рассматривали: участники команды или владельцы кода.
+	// Автора, неактивных и уже назначенных choose отсеивает сам.
+	pool  []model.User
+	count int
+	// seniorOnly — подходят только senior.
+	seniorOnly bool
+	// shadow — выбирается теневой ревьювер: подопечных не отсеивать.
+	shadow bool
+}
+
+// choose выбирает ревьюверов по sel и записывает выбор в журнал операции.
+func (p *picker) choose(ctx context.Context, pr *model.PullRequest, sel selection) ([]model.User, error) {
+	if sel.count <= 0 || len(sel.pool) == 0 {
+		return nil, nil
+	}
+
+	step := p.newStep(sel)
+	available, err := p.eligible(ctx, pr, sel, &step)
+	if err != nil {
+		return nil, err
+	}
+
+	// сначала тех, у кого рабочее время, остальных — только если не хватило
+	preferred, offHours, err := p.inHours(ctx, pr, available)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range offHours {
+		step.OffHours = append(step.OffHours, c.ID)
+	}
+
+	var chosen []model.User
+	for _, tier := range [][]model.User{preferred, offHours} {
+		rest := sel.count - len(chosen)
+		if rest <= 0 || len(tier) == 0 {
+			continue
+		}
+		more, err := p.svc.strategyFor(sel.team).Choose(ctx, SelectionRequest{
+			Tx:         p.tx,
+			Team:       sel.team,
+			PR:         pr,
+			Candidates: tier,
+			Count:      rest,
+			Rand:       p.svc.rand,
+			Now:        p.now,
+			Rotate:     sel.rotates(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		chosen = append(chosen, more...)
+	}
+
+	for _, c := range chosen {
+		step.Chosen = append(step.Chosen, c.ID)
+	}
+	p.steps = append(p.steps, step)
+	return chosen, nil
+}
+
+// rotates сообщает, продвигает ли выбор очередь команды: да, если ревьювер
+// выбирается из всей команды, а не из владельцев, senior или подопечных.
+func (sel selection) rotates() bool {
+	switch sel.purpose {
+	case purposeTeam, purposeFallback, purposeReplacement:
+		return true
+	}
+	return false
+}
+
+func (p *picker) newStep(sel selection) model.SelectionStep {
+	return model.SelectionStep{
+		Purpose:  sel.purpose,
+		Team:     sel.team.Name,
+		Strategy: p.svc.strategyName(sel.team),
+		Count:    sel.count,
+		Pool:     make([]string, 0, len(sel.pool)),
+		Chosen:   []string{},
+	}
+}
+
+// eligible возвращает кандидатов из sel.pool, которых сейчас можно назначить
+// на pr, и отмечает в step остальных.
+func (p *picker) eligible(ctx context.Context, pr *model.PullRequest, sel selection, step *model.SelectionStep) ([]model.User, error) {
+	candidates := make([]model.User, 0, len(sel.pool))
+	for _, u := range sel.pool {
+		step.Pool = append(step.Pool, u.ID)
+		reason := p.exclusion(pr, u, sel)
+		if reason == "" && !sel.shadow {
+			mentee, err := p.isMentee(ctx, u)
+			if err != nil {
+				return nil, err
+			}
+			if mentee {
+				reason = reasonMentee
+			}
+		}
+		if reason != "" {
+			step.Excluded = append(step.Excluded, model.Exclusion{UserID: u.ID, Reason: reason})
+			continue
+		}
+		candidates = append(candidates, u)
+	}
+	return p.available(ctx, candidates, step)
+}
+
+// exclusion возвращает, почему u нельзя назначить на pr; "" — можно.
+func (p *picker) exclusion(pr *model.PullRequest, u model.User, sel selection) string {
+	switch {
+	case u.ID == pr.AuthorID:
+		return reasonAuthor
+	case slices.Contains(p.excluded, u.ID):
+		return reasonExcluded
+	case u.ID == p.replaced:
+		return reasonReplaced
+	case !u.IsActive:
+		return reasonInactive
+	case slices.Contains(pr.AssignedReviewers, u.ID):
+		return reasonAlreadyAssigned
+	case sel.seniorOnly && u.Settings.Level != model.LevelSenior:
+		return reasonNotSenior
+	}
+	return ""
+}
+
+// available убирает кандидатов, которые сейчас отсутствуют или у которых
+// открытых ревью уже столько, сколько им можно, и отмечает их в step.
+func (p *picker) available(ctx context.Context, candidates []model.User, step *model.SelectionStep) ([]model.User, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	absent, err := p.tx.AbsentAt(ctx, ids, p.now)
+	if err != nil {
+		return nil, err
+	}
+	load, err := p.tx.CountOpenReviews(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.User, 0, len(candidates))
+	for _, c := range candidates {
+		if absent[c.ID] {
+			step.Excluded = append(step.Excluded, model.Exclusion{UserID: c.ID, Reason: reasonAbsent})
+			continue
+		}
+		limit, err := p.capacity(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		if limit > 0 && load[c.ID] >= limit {
+			if !slices.Contains(p.atCapacity, c.ID) {
+				p.atCapacity = append(p.atCapacity, c.ID)
+			}
+			step.Excluded = append(step.Excluded, model.Exclusion{UserID: c.ID, Reason: reasonAtCapacity})
+			continue
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// capacity возвращает лимит открытых ревью пользователя: личный,
+// а если его нет — его команды. 0 — без ограничения.
+func (p *picker) capacity(ctx context.Context, u model.User) (int, error) {
+	if u.Settings.MaxOpenReviews > 0 {
+		return u.Settings.MaxOpenReviews, nil
+	}
+	team, err := p.team(ctx, u.TeamName)
+	if err != nil || team == nil {
+		return 0, err
+	}
+	return team.Settings.MaxOpenReviews, nil
+}
+
+func (p *picker) team(ctx context.Context, name string) (*model.Team, error) {
+	if team, ok := p.teams[name]; ok {
+		return team, nil
+	}
+	team, err := p.tx.GetTeam(ctx, name)
+	if errors.Is(err, repo.ErrNotFound) {
+		team, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.teams[name] = team
+	return team, nil
+}
+
+// record сохраняет выборы операции в журнал назначений PR.
+func (p *picker) record(ctx context.Context, pr *model.PullRequest, action string, assigned []string) error {
+	steps := p.steps
+	if steps == nil {
+		steps = []model.SelectionStep{}
+	}
+	return p.tx.AddAssignmentRecord(ctx, model.AssignmentRecord{
+		PullRequestID:  pr.ID,
+		Action:         action,
+		ReplacedUserID: p.replaced,
+		Assigned:       append([]string{}, assigned...),
+		Steps:          steps,
+		CreatedAt:      p.now,
+	})
+}
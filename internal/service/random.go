@@ -0,0 +1,25 @@
+package service
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// lockedSource делает источник случайных чисел безопасным для параллельных
+// запросов: сам rand.Source этого не гарантирует.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// ErrInvalidReviewers — обязательные или исключённые ревьюверы PR заданы неверно.
+var ErrInvalidReviewers = errors.New("invalid required or excluded reviewers")
+
+// validateReviewerOptions проверяет обязательных и исключённых ревьюверов
+// из запроса на создание PR: обязательные должны существовать и не быть автором.
+func validateReviewerOptions(ctx context.Context, tx repo.Repository, authorID string, opts CreatePROptions) error {
+	if err := model.CheckReviewerLists(opts.RequiredReviewers, opts.ExcludedReviewers); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReviewers, err)
+	}
+	for _, id := range opts.RequiredReviewers {
+		if id == authorID {
+			return fmt.Errorf("%w: author %s cannot review own PR", ErrInvalidReviewers, id)
+		}
+		_, err := tx.GetUserByID(ctx, id)
+		if errors.Is(err, repo.ErrNotFound) {
+			return fmt.Errorf("%w: unknown user %s", ErrInvalidReviewers, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeIDs объединяет списки id без повторов, сохраняя порядок.
+func mergeIDs(lists ...[]string) []string {
+	var result []string
+	for _, list := range lists {
+		for _, id := range list {
+			if !slices.Contains(result, id) {
+				result = append(result, id)
+			}
+		}
+	}
+	return result
+}
+
+// prExclusions возвращает, кого нельзя назначать на pr: исключения самого PR
+// и текущие правила его автора.
+func prExclusions(ctx context.Context, tx repo.Repository, pr *model.PullRequest) ([]string, error) {
+	author, err := tx.GetUserByID(ctx, pr.AuthorID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return pr.ExcludedReviewers, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mergeIDs(pr.ExcludedReviewers, author.Settings.ExcludedReviewers), nil
+}
+
+// chooseRequired назначает на PR всех обязательных ревьюверов, кого сейчас
+// можно назначить: без стратегии и без учёта рабочего времени. Исключения,
+// отсутствие и лимит открытых ревью действуют и на них. Возвращает тех,
+// кого назначить не удалось.
+func (p *picker) chooseRequired(ctx context.Context, team *model.Team, pr *model.PullRequest, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var missed []string
+	pool := make([]model.User, 0, len(ids))
+	for _, id := range ids {
+		u, err := p.tx.GetUserByID(ctx, id)
+		if errors.Is(err, repo.ErrNotFound) {
+			missed = append(missed, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pool = append(pool, *u)
+	}
+
+	sel := selection{purpose: purposeRequired, team: team, pool: pool, count: len(ids)}
+	step := p.newStep(sel)
+	step.Strategy = ""
+	chosen, err := p.eligible(ctx, pr, sel, &step)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chosen {
+		step.Chosen = append(step.Chosen, c.ID)
+		pr.AssignedReviewers = append(pr.AssignedReviewers, c.ID)
+	}
+	for _, u := range pool {
+		if !slices.Contains(step.Chosen, u.ID) {
+			missed = append(missed, u.ID)
+		}
+	}
+	p.steps = append(p.steps, step)
+	return missed, nil
+}
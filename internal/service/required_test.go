@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+func TestRequiredExcluded(t *testing.T) {
+	tests := []struct {
+		name   string
+		count  int
+		opts   service.CreatePROptions
+		author model.UserSettings // правила автора a
+		want   []string           // должны быть назначены
+		never  []string           // не должны быть назначены
+		total  int
+	}{
+		{
+			name:  "required counts toward count",
+			count: 2,
+			opts:  service.CreatePROptions{RequiredReviewers: []string{"e"}},
+			want:  []string{"e"},
+			total: 2,
+		},
+		{
+			name:  "more required than count",
+			count: 2,
+			opts:  service.CreatePROptions{RequiredReviewers: []string{"b", "c", "d"}},
+			want:  []string{"b", "c", "d"},
+			total: 3,
+		},
+		{
+			name:  "excluded never assigned",
+			count: 3,
+			opts:  service.CreatePROptions{ExcludedReviewers: []string{"b", "c"}},
+			want:  []string{"d", "e", "f"},
+			never: []string{"b", "c"},
+			total: 3,
+		},
+		{
+			name:   "author rules",
+			count:  2,
+			author: model.UserSettings{RequiredReviewers: []string{"b"}, ExcludedReviewers: []string{"c", "d"}},
+			want:   []string{"b"},
+			never:  []string{"c", "d"},
+			total:  2,
+		},
+		{
+			name:   "author rules merged with PR",
+			count:  3,
+			opts:   service.CreatePROptions{RequiredReviewers: []string{"f"}, ExcludedReviewers: []string{"e"}},
+			author: model.UserSettings{RequiredReviewers: []string{"b"}, ExcludedReviewers: []string{"c"}},
+			want:   []string{"b", "d", "f"},
+			never:  []string{"c", "e"},
+			total:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(0); seed < 20; seed++ {
+				settings := model.TeamSettings{ReviewerCount: tt.count, ReviewerStrategy: service.StrategyRandom}
+				s := newTeam(t, settings, []string{"a", "b", "c", "d", "e", "f"}, service.WithRand(rand.NewSource(seed)))
+				if _, err := s.SetUserSettings(context.Background(), "a", tt.author); err != nil {
+					t.Fatalf("SetUserSettings: %v", err)
+				}
+
+				got := createPR(t, s, "pr-1", "a", tt.opts)
+				if len(got) != tt.total {
+					t.Errorf("seed %d: assigned %v, want %d reviewers", seed, got, tt.total)
+				}
+				for _, id := range tt.want {
+					if !slices.Contains(got, id) {
+						t.Errorf("seed %d: assigned %v, want %s", seed, got, id)
+					}
+				}
+				for _, id := range tt.never {
+					if slices.Contains(got, id) {
+						t.Errorf("seed %d: assigned %v, excluded %s among them", seed, got, id)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRequiredNotAssigned(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, model.TeamSettings{ReviewerCount: 2}, []string{"a", "b", "c", "d"})
+	if _, err := s.SetUserIsActive(ctx, "b", false); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+
+	res, err := s.CreatePullRequest(ctx, "pr-1", "change", "a", service.CreatePROptions{RequiredReviewers: []string{"b"}})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if !slices.Equal(res.RequiredNotAssigned, []string{"b"}) {
+		t.Errorf("required not assigned %v, want [b]", res.RequiredNotAssigned)
+	}
+	// место b занимают другие участники команды
+	if got := res.PR.AssignedReviewers; len(got) != 2 || slices.Contains(got, "b") {
+		t.Errorf("assigned %v, want c and d", got)
+	}
+}
+
+func TestExcludedReassign(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("PR exclusions", func(t *testing.T) {
+		s := newTeam(t, model.TeamSettings{ReviewerCount: 1}, []string{"a", "b", "c"})
+		createPR(t, s, "pr-1", "a", service.CreatePROptions{RequiredReviewers: []string{"b"}, ExcludedReviewers: []string{"c"}})
+
+		_, err := s.ReassignReviewer(ctx, "pr-1", "b", 0)
+		if !errors.Is(err, service.ErrNoCandidate) {
+			t.Errorf("ReassignReviewer: err = %v, want ErrNoCandidate", err)
+		}
+	})
+
+	t.Run("author rules added later", func(t *testing.T) {
+		s := newTeam(t, model.TeamSettings{ReviewerCount: 1}, []string{"a", "b", "c"})
+		createPR(t, s, "pr-1", "a", service.CreatePROptions{RequiredReviewers: []string{"b"}})
+		if _, err := s.SetUserSettings(ctx, "a", model.UserSettings{ExcludedReviewers: []string{"c"}}); err != nil {
+			t.Fatalf("SetUserSettings: %v", err)
+		}
+
+		_, err := s.ReassignReviewer(ctx, "pr-1", "b", 0)
+		if !errors.Is(err, service.ErrNoCandidate) {
+			t.Errorf("ReassignReviewer: err = %v, want ErrNoCandidate", err)
+		}
+	})
+}
+
+func TestInvalidReviewers(t *testing.T) {
+	tests := []struct {
+		name string
+		opts service.CreatePROptions
+	}{
+		{"author required", service.CreatePROptions{RequiredReviewers: []string{"a"}}},
+		{"unknown required", service.CreatePROptions{RequiredReviewers: []string{"x"}}},
+		{"empty required", service.CreatePROptions{RequiredReviewers: []string{""}}},
+		{"empty excluded", service.CreatePROptions{ExcludedReviewers: []string{""}}},
+		{
+			"both required and excluded",
+			service.CreatePROptions{RequiredReviewers: []string{"b"}, ExcludedReviewers: []string{"b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTeam(t, model.TeamSettings{}, []string{"a", "b", "c"})
+			_, err := s.CreatePullRequest(context.Background(), "pr-1", "change", "a", tt.opts)
+			if !errors.Is(err, service.ErrInvalidReviewers) {
+				t.Errorf("CreatePullRequest: err = %v, want ErrInvalidReviewers", err)
+			}
+		})
+	}
+}
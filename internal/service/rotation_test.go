@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+var roundRobin = model.TeamSettings{ReviewerCount: 2, ReviewerStrategy: service.StrategyRoundRobin}
+
+func TestRoundRobinOrder(t *testing.T) {
+	s := newTeam(t, roundRobin, []string{"a", "b", "c", "d", "e", "f"})
+
+	// автор a в очередь не попадает, после f очередь идёт снова с b
+	want := [][]string{{"b", "c"}, {"d", "e"}, {"f", "b"}, {"c", "d"}, {"e", "f"}}
+	for i, w := range want {
+		got := createPR(t, s, fmt.Sprintf("pr-%d", i), "a", service.CreatePROptions{})
+		if !slices.Equal(got, w) {
+			t.Errorf("PR %d: assigned %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestRoundRobinMembershipChanges(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, roundRobin, []string{"a", "b", "c", "d", "e", "f"})
+
+	if got := createPR(t, s, "pr-1", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"b", "c"}) {
+		t.Fatalf("pr-1: assigned %v, want [b c]", got)
+	}
+
+	// c — курсор очереди — уходит в другую команду, e выключают:
+	// очередь продолжается со следующего после c id и пропускает e
+	if _, err := s.CreateTeam(ctx, "frontend", users("c"), model.TeamSettings{}); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if _, err := s.SetUserIsActive(ctx, "e", false); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+	if got := createPR(t, s, "pr-2", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"d", "f"}) {
+		t.Errorf("pr-2: assigned %v, want [d f]", got)
+	}
+
+	// e вернулся и снова в очереди на своём месте
+	if _, err := s.SetUserIsActive(ctx, "e", true); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+	if got := createPR(t, s, "pr-3", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"b", "d"}) {
+		t.Errorf("pr-3: assigned %v, want [b d]", got)
+	}
+	if got := createPR(t, s, "pr-4", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"e", "f"}) {
+		t.Errorf("pr-4: assigned %v, want [e f]", got)
+	}
+}
+
+func TestRoundRobinReassign(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, roundRobin, []string{"a", "b", "c", "d", "e", "f"})
+
+	createPR(t, s, "pr-1", "a", service.CreatePROptions{}) // [b c]
+	res, err := s.ReassignReviewer(ctx, "pr-1", "b", 0)
+	if err != nil {
+		t.Fatalf("ReassignReviewer: %v", err)
+	}
+	if res.Replacement.UserID != "d" {
+		t.Errorf("replacement %s, want d", res.Replacement.UserID)
+	}
+	// замена тоже занимает очередь
+	if got := createPR(t, s, "pr-2", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"e", "f"}) {
+		t.Errorf("pr-2: assigned %v, want [e f]", got)
+	}
+}
+
+func TestRoundRobinSurvivesReopen(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, dir string) (repo.Repository, func() error)
+	}{
+		{"journal", func(t *testing.T, dir string) (repo.Repository, func() error) {
+			r, err := repo.OpenMemoryRepo(dir, 2)
+			if err != nil {
+				t.Fatalf("OpenMemoryRepo: %v", err)
+			}
+			return r, r.Close
+		}},
+		{"sqlite", func(t *testing.T, dir string) (repo.Repository, func() error) {
+			r, err := repo.OpenSQLite(filepath.Join(dir, "repo.db"))
+			if err != nil {
+				t.Fatalf("OpenSQLite: %v", err)
+			}
+			if _, err := r.MigrateUp(); err != nil {
+				t.Fatalf("MigrateUp: %v", err)
+			}
+			return r, r.Close
+		}},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			r, closeRepo := b.open(t, dir)
+			s := service.NewService(r)
+			if _, err := s.CreateTeam(context.Background(), "backend", users("a", "b", "c", "d", "e", "f"), roundRobin); err != nil {
+				t.Fatalf("CreateTeam: %v", err)
+			}
+			createPR(t, s, "pr-1", "a", service.CreatePROptions{})
+			createPR(t, s, "pr-2", "a", service.CreatePROptions{})
+			if err := closeRepo(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			r, closeRepo = b.open(t, dir)
+			defer closeRepo()
+			s = service.NewService(r)
+			if got := createPR(t, s, "pr-3", "a", service.CreatePROptions{}); !slices.Equal(got, []string{"f", "b"}) {
+				t.Errorf("after reopen: assigned %v, want [f b]", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// ErrNoSenior — команда требует senior-ревьювера, а назначить некого.
+var ErrNoSenior = errors.New("no senior reviewer available")
+
+// hasSenior сообщает, есть ли среди reviewerIDs senior.
+func hasSenior(ctx context.Context, tx repo.Repository, reviewerIDs []string) (bool, error) {
+	for _, id := range reviewerIDs {
+		u, err := tx.GetUserByID(ctx, id)
+		if errors.Is(err, repo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if u.Settings.Level == model.LevelSenior {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// needsSenior сообщает, нужно ли по правилу home добавить на PR senior,
+// если ревьюверами останутся reviewerIDs.
+func needsSenior(ctx context.Context, tx repo.Repository, home *model.Team, reviewerIDs []string) (bool, error) {
+	if home == nil || !home.Settings.RequireSenior {
+		return false, nil
+	}
+	ok, err := hasSenior(ctx, tx, reviewerIDs)
+	return !ok, err
+}
+
+// chooseSenior назначает на PR одного senior из команды home или её запасных
+// команд, если этого требует правило home и senior ещё не назначен.
+// Очередь round-robin этот выбор не сдвигает (см. selection.rotates).
+func (p *picker) chooseSenior(ctx context.Context, home *model.Team, pr *model.PullRequest) error {
+	need, err := needsSenior(ctx, p.tx, home, pr.AssignedReviewers)
+	if err != nil || !need {
+		return err
+	}
+
+	fallbacks, err := fallbackTeams(ctx, p.tx, home)
+	if err != nil {
+		return err
+	}
+	for _, team := range append([]*model.Team{home}, fallbacks...) {
+		chosen, err := p.choose(ctx, pr, selection{purpose: purposeSenior, team: team, pool: team.Members, count: 1, seniorOnly: true})
+		if err != nil {
+			return err
+		}
+		if len(chosen) > 0 {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, chosen[0].ID)
+			return nil
+		}
+	}
+	return ErrNoSenior
+}
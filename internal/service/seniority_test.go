@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+// setLevel задаёт уровень пользователя.
+func setLevel(t *testing.T, s *service.Service, level model.Level, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if _, err := s.SetUserSettings(context.Background(), id, model.UserSettings{Level: level}); err != nil {
+			t.Fatalf("SetUserSettings(%s): %v", id, err)
+		}
+	}
+}
+
+func TestRequireSenior(t *testing.T) {
+	settings := model.TeamSettings{ReviewerCount: 2, ReviewerStrategy: service.StrategyRandom, RequireSenior: true}
+	ids := []string{"a", "b", "c", "d", "e", "f"}
+
+	for seed := int64(0); seed < 10; seed++ {
+		s := newTeam(t, settings, ids, service.WithRand(rand.NewSource(seed)))
+		setLevel(t, s, model.LevelSenior, "e")
+		setLevel(t, s, model.LevelJunior, "b", "c", "d", "f")
+
+		got := createPR(t, s, "pr-1", "a", service.CreatePROptions{})
+		if len(got) != 2 || got[0] != "e" {
+			t.Errorf("seed %d: assigned %v, want senior e first and one more", seed, got)
+		}
+	}
+}
+
+func TestRequireSeniorUnavailable(t *testing.T) {
+	ctx := context.Background()
+	settings := model.TeamSettings{ReviewerCount: 2, RequireSenior: true}
+	s := newTeam(t, settings, []string{"a", "b", "c", "e"})
+	setLevel(t, s, model.LevelSenior, "e")
+
+	if _, err := s.SetUserIsActive(ctx, "e", false); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+	_, err := s.CreatePullRequest(ctx, "pr-1", "change", "a", service.CreatePROptions{})
+	if !errors.Is(err, service.ErrNoSenior) {
+		t.Errorf("CreatePullRequest without seniors: err = %v, want ErrNoSenior", err)
+	}
+}
+
+func TestReassignOnlySenior(t *testing.T) {
+	ctx := context.Background()
+	settings := model.TeamSettings{ReviewerCount: 1, ReviewerStrategy: service.StrategyRandom, RequireSenior: true}
+	ids := []string{"a", "b", "c", "d", "e", "f"}
+
+	for seed := int64(0); seed < 10; seed++ {
+		s := newTeam(t, settings, ids, service.WithRand(rand.NewSource(seed)))
+		setLevel(t, s, model.LevelSenior, "e", "f")
+
+		got := createPR(t, s, "pr-1", "a", service.CreatePROptions{})
+		if len(got) != 1 || (got[0] != "e" && got[0] != "f") {
+			t.Fatalf("seed %d: assigned %v, want one senior", seed, got)
+		}
+
+		// у PR один ревьювер — senior, поэтому заменить его можно только другим senior
+		res, err := s.ReassignReviewer(ctx, "pr-1", got[0], 0)
+		if err != nil {
+			t.Fatalf("seed %d: ReassignReviewer: %v", seed, err)
+		}
+		other := map[string]string{"e": "f", "f": "e"}[got[0]]
+		if res.Replacement.UserID != other {
+			t.Errorf("seed %d: replaced %s with %s, want senior %s", seed, got[0], res.Replacement.UserID, other)
+		}
+
+		// второго senior больше нет — заменить некем
+		if _, err := s.SetUserIsActive(ctx, got[0], false); err != nil {
+			t.Fatalf("SetUserIsActive: %v", err)
+		}
+		if _, err := s.ReassignReviewer(ctx, "pr-1", other, 0); !errors.Is(err, service.ErrNoSenior) {
+			t.Errorf("seed %d: ReassignReviewer without seniors: err = %v, want ErrNoSenior", seed, err)
+		}
+	}
+}
+
+func TestSeniorKeepsRotation(t *testing.T) {
+	settings := model.TeamSettings{ReviewerCount: 2, ReviewerStrategy: service.StrategyRoundRobin, RequireSenior: true}
+	s := newTeam(t, settings, []string{"a", "b", "c", "d", "e", "f"})
+	setLevel(t, s, model.LevelSenior, "b")
+
+	// senior b назначается на каждый PR, а второе место идёт по очереди
+	want := []string{"c", "d", "e", "f", "c"}
+	for i, w := range want {
+		got := createPR(t, s, fmt.Sprintf("pr-%d", i), "a", service.CreatePROptions{})
+		if !slices.Equal(got, []string{"b", w}) {
+			t.Errorf("PR %d: assigned %v, want [b %s]", i, got, w)
+		}
+	}
+}
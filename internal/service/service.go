@@ -1,20 +1,65 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"slices"
 	"time"
 
+	"github.com/iamyblitz/pr-reviewer-service/internal/codeowners"
 	"github.com/iamyblitz/pr-reviewer-service/internal/model"
 	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
 )
 
 type Service struct {
-	repo repo.Repository
+	repo     repo.Repository
+	strategy string // стратегия по умолчанию для команд без своей
+	rand     *rand.Rand
+	clock    func() time.Time
 }
 
-func NewService(r repo.Repository) *Service {
-	return &Service{repo: r}
+type Option func(*Service)
+
+// WithStrategy задаёт глобальную стратегию выбора ревьюверов
+// (по умолчанию StrategyLeastLoaded). Имя должно быть известным, см. KnownStrategy.
+func WithStrategy(name string) Option {
+	return func(s *Service) {
+		s.strategy = name
+	}
+}
+
+// WithRand задаёт источник случайных чисел для стратегий: с источником
+// от фиксированного seed выбор ревьюверов воспроизводим. По умолчанию
+// seed берётся из текущего времени.
+func WithRand(src rand.Source) Option {
+	return func(s *Service) {
+		s.rand = rand.New(&lockedSource{src: src})
+	}
+}
+
+// WithClock задаёт часы, по которым проставляются createdAt, mergedAt
+// и время назначений (по умолчанию time.Now).
+func WithClock(clock func() time.Time) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+func NewService(r repo.Repository, opts ...Option) *Service {
+	s := &Service{repo: r, strategy: StrategyLeastLoaded, clock: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.rand == nil {
+		s.rand = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
+	}
+	return s
+}
+
+func (s *Service) now() time.Time {
+	return s.clock().UTC()
 }
 
 var (
@@ -24,15 +69,29 @@ var (
 	ErrPRMerged    = errors.New("pr already merged")
 	ErrNotAssigned = errors.New("reviewer not assigned to this PR")
 	ErrNoCandidate = errors.New("no active replacement candidate in team")
+
+	// ErrVersionConflict — версия PR не совпала с ожидаемой клиентом
+	// или PR успели изменить параллельно.
+	ErrVersionConflict = errors.New("version conflict")
 )
 
-func (s *Service) CreateTeam(name string, members []model.User) (*model.Team, error) {
+func (s *Service) CreateTeam(ctx context.Context, name string, members []model.User, settings model.TeamSettings) (*model.Team, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if err := m.Settings.Validate(); err != nil {
+			return nil, fmt.Errorf("user %s: %w", m.ID, err)
+		}
+	}
+
 	team := &model.Team{
-		Name:    name,
-		Members: members,
+		Name:     name,
+		Members:  members,
+		Settings: settings,
 	}
 
-	err := s.repo.CreateTeam(team)
+	err := s.repo.CreateTeam(ctx, team)
 	if err != nil {
 		if errors.Is(err, repo.ErrTeamExists) {
 			return nil, ErrTeamExists
@@ -43,8 +102,8 @@ func (s *Service) CreateTeam(name string, members []model.User) (*model.Team, er
 	return team, nil
 }
 
-func (s *Service) GetTeam(name string) (*model.Team, error) {
-	team, err := s.repo.GetTeam(name)
+func (s *Service) GetTeam(ctx context.Context, name string) (*model.Team, error) {
+	team, err := s.repo.GetTeam(ctx, name)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			return nil, ErrNotFound
@@ -54,8 +113,8 @@ func (s *Service) GetTeam(name string) (*model.Team, error) {
 	return team, nil
 }
 
-func (s *Service) SetUserIsActive(userID string, isActive bool) (*model.User, error) {
-	user, err := s.repo.SetUserActive(userID, isActive)
+func (s *Service) SetUserIsActive(ctx context.Context, userID string, isActive bool) (*model.User, error) {
+	user, err := s.repo.SetUserActive(ctx, userID, isActive)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			return nil, ErrNotFound
@@ -65,108 +124,252 @@ func (s *Service) SetUserIsActive(userID string, isActive bool) (*model.User, er
 	return user, nil
 }
 
-func (s *Service) CreatePullRequest(id, name, authorID string) (*model.PullRequest, error) {
+// CreatePROptions — необязательные параметры создания PR.
+type CreatePROptions struct {
+	// ReviewerCount — сколько ревьюверов назначить; 0 — по настройкам команды.
+	ReviewerCount int
+	// ChangedPaths — файлы, которые затрагивает PR; по ним назначаются
+	// владельцы из CODEOWNERS команды автора.
+	ChangedPaths []string
+	// RequiredReviewers — кого назначить обязательно, вдобавок к правилам автора;
+	// они входят в ReviewerCount. ExcludedReviewers — кого не назначать на этот
+	// PR ни при создании, ни при переназначении.
+	RequiredReviewers []string
+	ExcludedReviewers []string
+}
 
-	author, err := s.repo.GetUserByID(authorID)
-	if err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, ErrNotFound
-		}
-		return nil, err
+// CreatePRResult — созданный PR и сведения о назначении ревьюверов.
+type CreatePRResult struct {
+	PR *model.PullRequest
+	// RequestedReviewers — сколько ревьюверов требовалось назначить;
+	// если кандидатов не хватило, назначено меньше.
+	RequestedReviewers int
+	// UncoveredPaths — пути, у владельцев которых никого не удалось назначить.
+	UncoveredPaths []string
+	// Assignments — назначенные ревьюверы в порядке AssignedReviewers.
+	Assignments []Assignment
+	// AtCapacity — кандидаты, пропущенные из-за лимита открытых ревью.
+	AtCapacity []string
+	// RequiredNotAssigned — обязательные ревьюверы, которых назначить не удалось.
+	RequiredNotAssigned []string
+}
+
+// MissingReviewers возвращает, скольких ревьюверов не хватило.
+func (r *CreatePRResult) MissingReviewers() int {
+	if n := r.RequestedReviewers - len(r.PR.AssignedReviewers); n > 0 {
+		return n
 	}
+	return 0
+}
 
-	team, err := s.repo.GetTeam(author.TeamName)
-	if err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, ErrNotFound
+func (s *Service) CreatePullRequest(ctx context.Context, id, name, authorID string, opts CreatePROptions) (*CreatePRResult, error) {
+	var (
+		pr         *model.PullRequest
+		count      int
+		uncovered  []string
+		assigned   []Assignment
+		atCapacity []string
+		missed     []string
+	)
+
+	err := s.repo.WithTx(ctx, func(tx repo.Repository) error {
+		author, err := tx.GetUserByID(ctx, authorID)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
 		}
-		return nil, err
-	}
 
-	candidates := make([]model.User, 0, len(team.Members))
-	for _, m := range team.Members {
-		if m.ID == authorID {
-			continue
+		team, err := tx.GetTeam(ctx, author.TeamName)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
 		}
-		if !m.IsActive {
-			continue
+
+		count, err = reviewerCount(team.Settings, opts.ReviewerCount)
+		if err != nil {
+			return err
+		}
+		if err := validateReviewerOptions(ctx, tx, authorID, opts); err != nil {
+			return err
 		}
-		candidates = append(candidates, m)
-	}
 
-	reviewerIDs := chooseReviewers(candidates, 2)
+		now := s.now()
+
+		pr = &model.PullRequest{
+			ID:                id,
+			Name:              name,
+			AuthorID:          authorID,
+			Status:            model.PRStatusOpen,
+			AssignedReviewers: []string{},
+			ChangedPaths:      cleanPaths(opts.ChangedPaths),
+			ExcludedReviewers: mergeIDs(opts.ExcludedReviewers),
+			CreatedAt:         &now,
+			MergedAt:          nil,
+		}
 
-	now := time.Now().UTC()
+		p := s.newPicker(tx, now)
+		p.excluded = mergeIDs(pr.ExcludedReviewers, author.Settings.ExcludedReviewers)
 
-	pr := &model.PullRequest{
-		ID:                id,
-		Name:              name,
-		AuthorID:          authorID,
-		Status:            model.PRStatusOpen,
-		AssignedReviewers: reviewerIDs,
-		CreatedAt:         &now,
-		MergedAt:          nil,
-	}
+		// сначала обязательных, затем по владельцу на каждую затронутую область, остальных — стратегией
+		required := mergeIDs(opts.RequiredReviewers, author.Settings.RequiredReviewers)
+		if missed, err = p.chooseRequired(ctx, team, pr, required); err != nil {
+			return err
+		}
+		areas, err := ownerAreas(ctx, tx, team, pr)
+		if err != nil {
+			return err
+		}
+		if uncovered, err = p.chooseOwners(ctx, team, pr, areas); err != nil {
+			return err
+		}
+		if err := p.chooseSenior(ctx, team, pr); err != nil {
+			return err
+		}
 
-	if err := s.repo.CreatePullRequest(pr); err != nil {
-		if errors.Is(err, repo.ErrPRExists) {
-			return nil, ErrPRExists
+		chosen, err := p.choose(ctx, pr, selection{purpose: purposeTeam, team: team, pool: team.Members, count: count - len(pr.AssignedReviewers)})
+		if err != nil {
+			return err
+		}
+		for _, c := range chosen {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, c.ID)
 		}
-		return nil, err
-	}
 
-	return pr, nil
-}
+		// своих не хватило — добираем из запасных команд
+		if err := p.chooseFromFallbacks(ctx, team, pr, count); err != nil {
+			return err
+		}
 
-func chooseReviewers(candidates []model.User, max int) []string {
-	if len(candidates) == 0 || max <= 0 {
-		return nil
-	}
+		// никого не назначили, потому что все свободные заняты, — не создаём PR
+		if len(pr.AssignedReviewers) == 0 && len(p.atCapacity) > 0 {
+			return ErrAtCapacity
+		}
+		atCapacity = p.atCapacity
+
+		if err := p.chooseShadow(ctx, team, pr); err != nil {
+			return err
+		}
 
-	rand.Shuffle(len(candidates), func(i, j int) {
-		candidates[i], candidates[j] = candidates[j], candidates[i]
+		if err := tx.CreatePullRequest(ctx, pr); err != nil {
+			if errors.Is(err, repo.ErrPRExists) {
+				return ErrPRExists
+			}
+			return err
+		}
+		if err := recordPairings(ctx, tx, pr, pr.AssignedReviewers, now); err != nil {
+			return err
+		}
+		if err := p.record(ctx, pr, model.ActionCreate, pr.AssignedReviewers); err != nil {
+			return err
+		}
+
+		assigned, err = assignments(ctx, tx, pr, team.Name)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	if len(candidates) > max {
-		candidates = candidates[:max]
+	return &CreatePRResult{
+		PR:                  pr,
+		RequestedReviewers:  count,
+		UncoveredPaths:      uncovered,
+		Assignments:         assigned,
+		AtCapacity:          atCapacity,
+		RequiredNotAssigned: missed,
+	}, nil
+}
+
+// recordPairings запоминает, что reviewerIDs назначены на PR его автора.
+func recordPairings(ctx context.Context, tx repo.Repository, pr *model.PullRequest, reviewerIDs []string, at time.Time) error {
+	pairings := make([]model.Pairing, 0, len(reviewerIDs))
+	for _, id := range reviewerIDs {
+		pairings = append(pairings, model.Pairing{
+			AuthorID:      pr.AuthorID,
+			ReviewerID:    id,
+			PullRequestID: pr.ID,
+			AssignedAt:    at,
+		})
 	}
+	return tx.AddPairings(ctx, pairings)
+}
 
-	result := make([]string, 0, len(candidates))
-	for _, c := range candidates {
-		result = append(result, c.ID)
+// cleanPaths приводит пути к общему виду и убирает пустые и повторы.
+func cleanPaths(paths []string) []string {
+	var result []string
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		p = codeowners.Clean(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		result = append(result, p)
 	}
 	return result
 }
 
-func (s *Service) MergePullRequest(prID string) (*model.PullRequest, error) {
-	pr, err := s.repo.GetPullRequestByID(prID)
+func (s *Service) GetPullRequest(ctx context.Context, prID string) (*model.PullRequest, error) {
+	pr, err := s.repo.GetPullRequestByID(ctx, prID)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			return nil, ErrNotFound
 		}
 		return nil, err
 	}
+	return pr, nil
+}
 
-	if pr.Status == model.PRStatusMerged {
-		return pr, nil
-	}
+// MergePullRequest помечает PR смёрженным. Если expectedVersion не 0,
+// операция выполняется только при совпадении версии PR.
+func (s *Service) MergePullRequest(ctx context.Context, prID string, expectedVersion int64) (*model.PullRequest, error) {
+	var pr *model.PullRequest
+
+	err := s.repo.WithTx(ctx, func(tx repo.Repository) error {
+		var err error
+		pr, err = tx.GetPullRequestByID(ctx, prID)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
 
-	now := time.Now().UTC()
-	pr.Status = model.PRStatusMerged
-	pr.MergedAt = &now
+		if expectedVersion != 0 && pr.Version != expectedVersion {
+			return ErrVersionConflict
+		}
 
-	if err := s.repo.UpdatePullRequest(pr); err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, ErrNotFound
+		if pr.Status == model.PRStatusMerged {
+			return nil
+		}
+
+		now := s.now()
+		pr.Status = model.PRStatusMerged
+		pr.MergedAt = &now
+
+		if err := tx.UpdatePullRequest(ctx, pr); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			if errors.Is(err, repo.ErrVersionConflict) {
+				return ErrVersionConflict
+			}
+			return err
 		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return pr, nil
 }
 
-func (s *Service) GetUserReviews(userID string) ([]model.PullRequest, error) {
-	_, err := s.repo.GetUserByID(userID)
+func (s *Service) GetUserReviews(ctx context.Context, userID string) ([]model.PullRequest, error) {
+	_, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			return nil, ErrNotFound
@@ -174,7 +377,7 @@ func (s *Service) GetUserReviews(userID string) ([]model.PullRequest, error) {
 		return nil, err
 	}
 
-	prs, err := s.repo.GetPullRequestsByReviewer(userID)
+	prs, err := s.repo.GetPullRequestsByReviewer(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -182,83 +385,185 @@ func (s *Service) GetUserReviews(userID string) ([]model.PullRequest, error) {
 	return prs, nil
 }
 
-func (s *Service) ReassignReviewer(prID, oldUserID string) (*model.PullRequest, string, error) {
-	pr, err := s.repo.GetPullRequestByID(prID)
-	if err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, "", ErrNotFound
+// ReassignResult — PR после переназначения и новый ревьювер.
+type ReassignResult struct {
+	PR          *model.PullRequest
+	Replacement Assignment
+}
+
+// ReassignReviewer заменяет ревьювера oldUserID. Если expectedVersion не 0,
+// операция выполняется только при совпадении версии PR.
+//
+// Замена ищется в команде старого ревьювера, затем в команде автора и в её
+// запасных командах; исключённых ревьюверов PR и автора не назначают. Если
+// команда автора требует senior, а старый ревьювер был единственным senior
+// на PR, замена тоже должна быть senior.
+func (s *Service) ReassignReviewer(ctx context.Context, prID, oldUserID string, expectedVersion int64) (*ReassignResult, error) {
+	var (
+		pr          *model.PullRequest
+		replacement Assignment
+	)
+
+	err := s.repo.WithTx(ctx, func(tx repo.Repository) error {
+		var err error
+		pr, err = tx.GetPullRequestByID(ctx, prID)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
 		}
-		return nil, "", err
-	}
 
-	if pr.Status == model.PRStatusMerged {
-		return nil, "", ErrPRMerged
-	}
+		if expectedVersion != 0 && pr.Version != expectedVersion {
+			return ErrVersionConflict
+		}
 
-	idx := -1
-	for i, r := range pr.AssignedReviewers {
-		if r == oldUserID {
-			idx = i
-			break
+		if pr.Status == model.PRStatusMerged {
+			return ErrPRMerged
 		}
-	}
-	if idx == -1 {
-		return nil, "", ErrNotAssigned
-	}
 
-	oldUser, err := s.repo.GetUserByID(oldUserID)
-	if err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, "", ErrNotFound
+		idx := -1
+		for i, r := range pr.AssignedReviewers {
+			if r == oldUserID {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return ErrNotAssigned
 		}
-		return nil, "", err
-	}
 
-	team, err := s.repo.GetTeam(oldUser.TeamName)
-	if err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, "", ErrNotFound
+		oldUser, err := tx.GetUserByID(ctx, oldUserID)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
 		}
-		return nil, "", err
-	}
 
-	assignedSet := make(map[string]struct{}, len(pr.AssignedReviewers))
-	for _, r := range pr.AssignedReviewers {
-		assignedSet[r] = struct{}{}
-	}
+		team, err := tx.GetTeam(ctx, oldUser.TeamName)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
 
-	candidates := make([]model.User, 0, len(team.Members))
-	for _, m := range team.Members {
-		if !m.IsActive {
-			continue
+		home, err := authorTeam(ctx, tx, pr)
+		if err != nil {
+			return err
 		}
-		if m.ID == oldUserID {
-			continue
+
+		// если старый ревьювер был единственным владельцем какой-то области,
+		// замену сначала ищем среди её владельцев
+		owners, err := reassignOwners(ctx, tx, home, pr, oldUserID)
+		if err != nil {
+			return err
 		}
-		if m.ID == pr.AuthorID {
-			continue
+
+		// если без старого ревьювера на PR не останется senior, а команда
+		// автора его требует, замену ищем только среди senior
+		remaining := slices.Delete(slices.Clone(pr.AssignedReviewers), idx, idx+1)
+		needSenior, err := needsSenior(ctx, tx, home, remaining)
+		if err != nil {
+			return err
 		}
-		if _, alreadyAssigned := assignedSet[m.ID]; alreadyAssigned {
-			continue
+
+		excluded, err := prExclusions(ctx, tx, pr)
+		if err != nil {
+			return err
 		}
-		candidates = append(candidates, m)
-	}
 
-	if len(candidates) == 0 {
-		return nil, "", ErrNoCandidate
-	}
+		now := s.now()
+		p := s.newPicker(tx, now)
+		p.replaced = oldUserID
+		p.excluded = excluded
+		chosen, err := p.choose(ctx, pr, selection{purpose: purposeOwner, team: team, pool: owners, count: 1, seniorOnly: needSenior})
+		if err != nil {
+			return err
+		}
 
-	newIdx := rand.Intn(len(candidates))
-	newReviewer := candidates[newIdx]
+		pools := []*model.Team{team}
+		if home != nil {
+			if home.Name != team.Name {
+				pools = append(pools, home)
+			}
+			fallbacks, err := fallbackTeams(ctx, tx, home, team.Name)
+			if err != nil {
+				return err
+			}
+			pools = append(pools, fallbacks...)
+		}
+		for _, pool := range pools {
+			if len(chosen) > 0 {
+				break
+			}
+			chosen, err = p.choose(ctx, pr, selection{purpose: purposeReplacement, team: pool, pool: pool.Members, count: 1, seniorOnly: needSenior})
+			if err != nil {
+				return err
+			}
+		}
+		if len(chosen) == 0 {
+			if len(p.atCapacity) > 0 {
+				return ErrAtCapacity
+			}
+			if needSenior {
+				return ErrNoSenior
+			}
+			return ErrNoCandidate
+		}
 
-	pr.AssignedReviewers[idx] = newReviewer.ID
+		pr.AssignedReviewers[idx] = chosen[0].ID
 
-	if err := s.repo.UpdatePullRequest(pr); err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, "", ErrNotFound
+		if err := tx.UpdatePullRequest(ctx, pr); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			if errors.Is(err, repo.ErrVersionConflict) {
+				return ErrVersionConflict
+			}
+			return err
 		}
-		return nil, "", err
+		if err := recordPairings(ctx, tx, pr, []string{chosen[0].ID}, now); err != nil {
+			return err
+		}
+		if err := p.record(ctx, pr, model.ActionReassign, []string{chosen[0].ID}); err != nil {
+			return err
+		}
+
+		homeName := oldUser.TeamName
+		if home != nil {
+			homeName = home.Name
+		}
+		replacement, err = assignmentOf(ctx, tx, chosen[0].ID, homeName)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return pr, newReviewer.ID, nil
+	return &ReassignResult{PR: pr, Replacement: replacement}, nil
+}
+
+// ExplainPullRequest возвращает журнал назначений PR: из кого и какой
+// стратегией выбирались ревьюверы и кого почему пропустили.
+func (s *Service) ExplainPullRequest(ctx context.Context, prID string) ([]model.AssignmentRecord, error) {
+	var records []model.AssignmentRecord
+
+	err := s.repo.WithTx(ctx, func(tx repo.Repository) error {
+		if _, err := tx.GetPullRequestByID(ctx, prID); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		var err error
+		records, err = tx.ListAssignmentRecords(ctx, prID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
 }
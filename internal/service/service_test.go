@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+// newTeam создаёт сервис на пустом MemoryRepo и команду с участниками ids.
+func newTeam(t *testing.T, settings model.TeamSettings, ids []string, opts ...service.Option) *service.Service {
+	t.Helper()
+	s := service.NewService(repo.NewMemoryRepo(), opts...)
+	if _, err := s.CreateTeam(context.Background(), "backend", users(ids...), settings); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	return s
+}
+
+// users возвращает активных пользователей с id ids.
+func users(ids ...string) []model.User {
+	members := make([]model.User, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, model.User{ID: id, Username: id, IsActive: true})
+	}
+	return members
+}
+
+// createPR создаёт PR от author и возвращает назначенных ревьюверов.
+func createPR(t *testing.T, s *service.Service, id, author string, opts service.CreatePROptions) []string {
+	t.Helper()
+	res, err := s.CreatePullRequest(context.Background(), id, "change", author, opts)
+	if err != nil {
+		t.Fatalf("CreatePullRequest(%s): %v", id, err)
+	}
+	return res.PR.AssignedReviewers
+}
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	merged := created.Add(26 * time.Hour)
+	now := created
+	clock := func() time.Time { return now }
+
+	s := newTeam(t, model.TeamSettings{}, []string{"author", "u1", "u2"}, service.WithClock(clock))
+
+	res, err := s.CreatePullRequest(ctx, "pr-1", "change", "author", service.CreatePROptions{})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if res.PR.CreatedAt == nil || !res.PR.CreatedAt.Equal(created) {
+		t.Errorf("createdAt = %v, want %v", res.PR.CreatedAt, created)
+	}
+	if res.PR.MergedAt != nil {
+		t.Errorf("mergedAt = %v, want nil", res.PR.MergedAt)
+	}
+
+	now = merged
+	pr, err := s.MergePullRequest(ctx, "pr-1", 0)
+	if err != nil {
+		t.Fatalf("MergePullRequest: %v", err)
+	}
+	if pr.MergedAt == nil || !pr.MergedAt.Equal(merged) {
+		t.Errorf("mergedAt = %v, want %v", pr.MergedAt, merged)
+	}
+
+	// повторный merge не сдвигает mergedAt
+	now = merged.Add(time.Hour)
+	if pr, err = s.MergePullRequest(ctx, "pr-1", 0); err != nil {
+		t.Fatalf("MergePullRequest again: %v", err)
+	}
+	if pr.MergedAt == nil || !pr.MergedAt.Equal(merged) {
+		t.Errorf("mergedAt after second merge = %v, want %v", pr.MergedAt, merged)
+	}
+
+	if pr, err = s.GetPullRequest(ctx, "pr-1"); err != nil {
+		t.Fatalf("GetPullRequest: %v", err)
+	}
+	if !pr.CreatedAt.Equal(created) || !pr.MergedAt.Equal(merged) {
+		t.Errorf("stored createdAt %v, mergedAt %v, want %v, %v", pr.CreatedAt, pr.MergedAt, created, merged)
+	}
+
+	records, err := s.ExplainPullRequest(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("ExplainPullRequest: %v", err)
+	}
+	if !records[0].CreatedAt.Equal(created) {
+		t.Errorf("assignment record at %v, want %v", records[0].CreatedAt, created)
+	}
+}
+
+// picks создаёт PR и переназначает первого ревьювера каждого, возвращая
+// всех выбранных ревьюверов по порядку.
+func picks(t *testing.T, seed int64) []string {
+	t.Helper()
+	ctx := context.Background()
+	settings := model.TeamSettings{ReviewerStrategy: service.StrategyRandom}
+	ids := []string{"author", "u1", "u2", "u3", "u4", "u5", "u6", "u7"}
+	s := newTeam(t, settings, ids, service.WithRand(rand.NewSource(seed)))
+
+	var got []string
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("pr-%d", i)
+		res, err := s.CreatePullRequest(ctx, id, "change", "author", service.CreatePROptions{})
+		if err != nil {
+			t.Fatalf("CreatePullRequest: %v", err)
+		}
+		got = append(got, res.PR.AssignedReviewers...)
+
+		re, err := s.ReassignReviewer(ctx, id, res.PR.AssignedReviewers[0], 0)
+		if err != nil {
+			t.Fatalf("ReassignReviewer: %v", err)
+		}
+		got = append(got, re.Replacement.UserID)
+	}
+	return got
+}
+
+func TestSeededPicks(t *testing.T) {
+	first, second := picks(t, 42), picks(t, 42)
+	if !slices.Equal(first, second) {
+		t.Errorf("same seed, different picks:\n%v\n%v", first, second)
+	}
+	if other := picks(t, 43); slices.Equal(first, other) {
+		t.Errorf("seeds 42 and 43 gave the same picks %v", first)
+	}
+}
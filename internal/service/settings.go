@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// DefaultReviewerCount — число ревьюверов, если команда его не задала.
+const DefaultReviewerCount = 2
+
+var (
+	// ErrInvalidSettings — настройки команды или пользователя не прошли проверку;
+	// подробности в тексте ошибки.
+	ErrInvalidSettings = model.ErrInvalidSettings
+
+	// ErrInvalidReviewerCount — запрошенное число ревьюверов вне пределов команды.
+	ErrInvalidReviewerCount = errors.New("reviewer count out of team limits")
+)
+
+// reviewerCount возвращает, сколько ревьюверов назначить на PR команды:
+// requested, если он задан и укладывается в пределы, иначе число из настроек.
+func reviewerCount(settings model.TeamSettings, requested int) (int, error) {
+	min, max := settings.ReviewerLimits()
+
+	if requested != 0 {
+		if requested < min || (max > 0 && requested > max) {
+			return 0, fmt.Errorf("%w: %d requested, team allows %s", ErrInvalidReviewerCount, requested, limitsString(min, max))
+		}
+		return requested, nil
+	}
+
+	n := settings.ReviewerCount
+	if n == 0 {
+		n = DefaultReviewerCount
+		if n < min {
+			n = min
+		}
+		if max > 0 && n > max {
+			n = max
+		}
+	}
+	return n, nil
+}
+
+func limitsString(min, max int) string {
+	if max == 0 {
+		return fmt.Sprintf("at least %d", min)
+	}
+	return fmt.Sprintf("%d to %d", min, max)
+}
+
+// SetTeamSettings заменяет настройки команды. Если expectedVersion не 0,
+// операция выполняется только при совпадении версии команды.
+func (s *Service) SetTeamSettings(ctx context.Context, name string, settings model.TeamSettings, expectedVersion int64) (*model.Team, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	var team *model.Team
+
+	err := s.repo.WithTx(ctx, func(tx repo.Repository) error {
+		current, err := tx.GetTeam(ctx, name)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if expectedVersion != 0 && current.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		team, err = tx.SetTeamSettings(ctx, name, settings)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// SetUserSettings заменяет личные настройки пользователя.
+func (s *Service) SetUserSettings(ctx context.Context, userID string, settings model.UserSettings) (*model.User, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.SetUserSettings(ctx, userID, settings)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+func TestReviewerCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  model.TeamSettings
+		requested int
+		want      int   // сколько назначено
+		err       error // ожидаемая ошибка
+	}{
+		{"default", model.TeamSettings{}, 0, 2, nil},
+		{"team count", model.TeamSettings{ReviewerCount: 3}, 0, 3, nil},
+		{"override within limits", model.TeamSettings{ReviewerCount: 1, MinReviewers: 1, MaxReviewers: 3}, 3, 3, nil},
+		{"override below min", model.TeamSettings{MinReviewers: 2}, 1, 0, service.ErrInvalidReviewerCount},
+		{"override above max", model.TeamSettings{MaxReviewers: 3}, 4, 0, service.ErrInvalidReviewerCount},
+		{"no upper limit", model.TeamSettings{MinReviewers: 1}, 4, 4, nil},
+		{"default clamped to max", model.TeamSettings{MaxReviewers: 1}, 0, 1, nil},
+		{"default raised to min", model.TeamSettings{MinReviewers: 3}, 0, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTeam(t, tt.settings, []string{"a", "b", "c", "d", "e"})
+			res, err := s.CreatePullRequest(context.Background(), "pr-1", "change", "a",
+				service.CreatePROptions{ReviewerCount: tt.requested})
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreatePullRequest: %v", err)
+			}
+			if got := len(res.PR.AssignedReviewers); got != tt.want || res.RequestedReviewers != tt.want {
+				t.Errorf("assigned %d of %d requested, want %d", got, res.RequestedReviewers, tt.want)
+			}
+			if res.MissingReviewers() != 0 {
+				t.Errorf("missing %d reviewers, want none", res.MissingReviewers())
+			}
+		})
+	}
+}
+
+func TestReviewerCountShortfall(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, model.TeamSettings{ReviewerCount: 3}, []string{"a", "b", "c"})
+	if _, err := s.SetUserIsActive(ctx, "c", false); err != nil {
+		t.Fatalf("SetUserIsActive: %v", err)
+	}
+
+	// кандидат один, а нужно три: PR создаётся, ответ показывает нехватку
+	res, err := s.CreatePullRequest(ctx, "pr-1", "change", "a", service.CreatePROptions{})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if res.RequestedReviewers != 3 || len(res.PR.AssignedReviewers) != 1 || res.MissingReviewers() != 2 {
+		t.Errorf("requested %d, assigned %v, missing %d; want 3, [b], 2",
+			res.RequestedReviewers, res.PR.AssignedReviewers, res.MissingReviewers())
+	}
+}
+
+func TestInvalidTeamSettings(t *testing.T) {
+	ctx := context.Background()
+	s := newTeam(t, model.TeamSettings{}, []string{"a", "b"})
+
+	for _, settings := range []model.TeamSettings{
+		{MinReviewers: 3, MaxReviewers: 2},
+		{ReviewerCount: 5, MaxReviewers: 3},
+		{ReviewerCount: -1},
+	} {
+		if _, err := s.SetTeamSettings(ctx, "backend", settings, 0); !errors.Is(err, service.ErrInvalidSettings) {
+			t.Errorf("SetTeamSettings(%+v) = %v, want ErrInvalidSettings", settings, err)
+		}
+	}
+}
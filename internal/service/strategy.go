@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/repo"
+)
+
+// ReviewerStrategy решает, кого из кандидатов назначить ревьювером.
+// Через неё проходят и первичное назначение, и переназначение.
+type ReviewerStrategy interface {
+	// Choose возвращает не больше req.Count кандидатов из req.Candidates.
+	Choose(ctx context.Context, req SelectionRequest) ([]model.User, error)
+}
+
+// SelectionRequest — всё, что стратегия знает о назначении.
+type SelectionRequest struct {
+	// Tx — текущая транзакция: стратегия может читать из неё данные
+	// и сохранять своё состояние.
+	Tx   repo.Repository
+	Team *model.Team
+	// PR — для которого выбираются ревьюверы. При создании он ещё не
+	// сохранён, AssignedReviewers — уже выбранные ревьюверы.
+	PR *model.PullRequest
+	// Candidates — активные участники команды, кроме автора и уже назначенных,
+	// у которых есть место для ещё одного ревью.
+	Candidates []model.User
+	Count      int
+	// Rand — источник случайности сервиса; стратегии не используют
+	// глобальный math/rand, чтобы выбор можно было воспроизвести.
+	Rand *rand.Rand
+	// Now — время операции.
+	Now time.Time
+	// Rotate — выбор продвигает очередь команды. Его ставят только выборы из
+	// всей команды: своей, запасной или при замене. Владельцы, senior и теневой
+	// ревьювер выбираются из очереди без сдвига, иначе она откатывалась бы
+	// к ним на каждом PR.
+	Rotate bool
+}
+
+const (
+	StrategyRandom       = model.StrategyRandom
+	StrategyLeastLoaded  = model.StrategyLeastLoaded
+	StrategyRoundRobin   = model.StrategyRoundRobin
+	StrategyPairingAware = model.StrategyPairingAware
+)
+
+// DefaultPairingWindowDays — за сколько дней pairing-aware учитывает прошлые
+// назначения, если команда не задала своё окно.
+const DefaultPairingWindowDays = 30
+
+// strategies — реализации стратегий; имена те же, что знает model.KnownStrategy.
+var strategies = map[string]ReviewerStrategy{
+	StrategyRandom:       randomStrategy{},
+	StrategyLeastLoaded:  leastLoadedStrategy{},
+	StrategyRoundRobin:   roundRobinStrategy{},
+	StrategyPairingAware: pairingAwareStrategy{},
+}
+
+// StrategyNames возвращает имена доступных стратегий.
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KnownStrategy сообщает, есть ли стратегия с таким именем.
+func KnownStrategy(name string) bool {
+	return model.KnownStrategy(name)
+}
+
+// strategyFor возвращает стратегию команды, а если она не задана — глобальную.
+func (s *Service) strategyFor(team *model.Team) ReviewerStrategy {
+	return strategies[s.strategyName(team)]
+}
+
+func (s *Service) strategyName(team *model.Team) string {
+	if KnownStrategy(team.Settings.ReviewerStrategy) {
+		return team.Settings.ReviewerStrategy
+	}
+	return s.strategy
+}
+
+// randomStrategy выбирает кандидатов случайно.
+type randomStrategy struct{}
+
+func (randomStrategy) Choose(_ context.Context, req SelectionRequest) ([]model.User, error) {
+	candidates := append([]model.User(nil), req.Candidates...)
+	req.Rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	if len(candidates) > req.Count {
+		candidates = candidates[:req.Count]
+	}
+	return candidates, nil
+}
+
+// leastLoadedStrategy выбирает кандидатов с наименьшим числом открытых ревью,
+// при равенстве — случайно.
+type leastLoadedStrategy struct{}
+
+func (leastLoadedStrategy) Choose(ctx context.Context, req SelectionRequest) ([]model.User, error) {
+	ids := make([]string, 0, len(req.Candidates))
+	for _, c := range req.Candidates {
+		ids = append(ids, c.ID)
+	}
+	load, err := req.Tx.CountOpenReviews(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := append([]model.User(nil), req.Candidates...)
+	req.Rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return load[candidates[i].ID] < load[candidates[j].ID]
+	})
+
+	if len(candidates) > req.Count {
+		candidates = candidates[:req.Count]
+	}
+	return candidates, nil
+}
+
+// roundRobinStrategy назначает участников команды по очереди в порядке id.
+// Курсор — id последнего назначенного — хранится в репозитории, поэтому
+// очередь переживает рестарт, а при смене состава просто продолжается
+// со следующего по порядку id. Курсор сдвигается, только если req.Rotate.
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Choose(ctx context.Context, req SelectionRequest) ([]model.User, error) {
+	if len(req.Candidates) == 0 || req.Count <= 0 {
+		return nil, nil
+	}
+
+	cursor, err := req.Tx.GetRotationCursor(ctx, req.Team.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := append([]model.User(nil), req.Candidates...)
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	// первый кандидат после курсора, дальше по кругу
+	start := sort.Search(len(candidates), func(i int) bool { return candidates[i].ID > cursor })
+	candidates = append(candidates[start:], candidates[:start]...)
+
+	if len(candidates) > req.Count {
+		candidates = candidates[:req.Count]
+	}
+
+	if !req.Rotate {
+		return candidates, nil
+	}
+	if err := req.Tx.SetRotationCursor(ctx, req.Team.Name, candidates[len(candidates)-1].ID); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// pairingAwareStrategy выбирает тех, кто реже всех ревьюил автора PR за
+// последние PairingWindowDays дней; при равенстве — менее загруженных,
+// дальше — случайно.
+type pairingAwareStrategy struct{}
+
+func (pairingAwareStrategy) Choose(ctx context.Context, req SelectionRequest) ([]model.User, error) {
+	ids := make([]string, 0, len(req.Candidates))
+	for _, c := range req.Candidates {
+		ids = append(ids, c.ID)
+	}
+
+	days := req.Team.Settings.PairingWindowDays
+	if days == 0 {
+		days = DefaultPairingWindowDays
+	}
+	since := req.Now.AddDate(0, 0, -days)
+
+	pairs, err := req.Tx.CountPairings(ctx, req.PR.AuthorID, ids, since)
+	if err != nil {
+		return nil, err
+	}
+	load, err := req.Tx.CountOpenReviews(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := append([]model.User(nil), req.Candidates...)
+	req.Rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].ID, candidates[j].ID
+		if pairs[a] != pairs[b] {
+			return pairs[a] < pairs[b]
+		}
+		return load[a] < load[b]
+	})
+
+	if len(candidates) > req.Count {
+		candidates = candidates[:req.Count]
+	}
+	return candidates, nil
+}
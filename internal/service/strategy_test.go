@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/iamyblitz/pr-reviewer-service/internal/model"
+	"github.com/iamyblitz/pr-reviewer-service/internal/service"
+)
+
+// addLoad создаёт n открытых PR, на каждом из которых единственный
+// ревьювер — reviewer. Автор — x из отдельной команды, чтобы не попасть
+// в кандидаты.
+func addLoad(t *testing.T, s *service.Service, reviewer string, n int) {
+	t.Helper()
+	if _, err := s.GetTeam(context.Background(), "load"); err != nil {
+		if _, err := s.CreateTeam(context.Background(), "load", users("x"), model.TeamSettings{}); err != nil {
+			t.Fatalf("CreateTeam: %v", err)
+		}
+	}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("load-%s-%d", reviewer, i)
+		createPR(t, s, id, "x", service.CreatePROptions{ReviewerCount: 1, RequiredReviewers: []string{reviewer}})
+	}
+}
+
+func TestLeastLoaded(t *testing.T) {
+	tests := []struct {
+		name  string
+		loads map[string]int
+		count int
+		want  [][]string // допустимые наборы ревьюверов (в любом порядке)
+	}{
+		{"minimum", map[string]int{"b": 3, "c": 1, "d": 0, "e": 2}, 1, [][]string{{"d"}}},
+		{"two lowest", map[string]int{"b": 3, "c": 1, "d": 0, "e": 2}, 2, [][]string{{"c", "d"}}},
+		{"tie", map[string]int{"b": 2, "c": 1, "d": 1, "e": 2}, 1, [][]string{{"c"}, {"d"}}},
+		{"all equal", map[string]int{"b": 1, "c": 1, "d": 1, "e": 1}, 1, [][]string{{"b"}, {"c"}, {"d"}, {"e"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[string]bool)
+			for seed := int64(0); seed < 20; seed++ {
+				settings := model.TeamSettings{ReviewerCount: tt.count, ReviewerStrategy: service.StrategyLeastLoaded}
+				s := newTeam(t, settings, []string{"a", "b", "c", "d", "e"}, service.WithRand(rand.NewSource(seed)))
+				for id, n := range tt.loads {
+					addLoad(t, s, id, n)
+				}
+
+				got := slices.Clone(createPR(t, s, "pr-1", "a", service.CreatePROptions{}))
+				slices.Sort(got)
+				ok := slices.ContainsFunc(tt.want, func(w []string) bool { return slices.Equal(got, w) })
+				if !ok {
+					t.Fatalf("seed %d: assigned %v, want one of %v", seed, got, tt.want)
+				}
+				seen[fmt.Sprint(got)] = true
+			}
+			// при равной нагрузке выбор случайный, а не всегда один и тот же
+			if len(tt.want) > 1 && len(seen) < 2 {
+				t.Errorf("ties always resolved to %v", seen)
+			}
+		})
+	}
+}
+
+func TestLeastLoadedReassign(t *testing.T) {
+	ctx := context.Background()
+	settings := model.TeamSettings{ReviewerCount: 1, ReviewerStrategy: service.StrategyLeastLoaded}
+	s := newTeam(t, settings, []string{"a", "b", "c", "d", "e"})
+	createPR(t, s, "pr-1", "a", service.CreatePROptions{RequiredReviewers: []string{"b"}})
+	addLoad(t, s, "c", 3)
+	addLoad(t, s, "d", 1)
+	addLoad(t, s, "e", 2)
+
+	res, err := s.ReassignReviewer(ctx, "pr-1", "b", 0)
+	if err != nil {
+		t.Fatalf("ReassignReviewer: %v", err)
+	}
+	if res.Replacement.UserID != "d" {
+		t.Errorf("replacement %s, want least loaded d", res.Replacement.UserID)
+	}
+}